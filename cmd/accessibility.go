@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
+)
+
+// accessibleEnv enables the accessible mode without passing --accessible every time
+const accessibleEnv = "GOBUDDY_ACCESSIBLE"
+
+var noColorFlag bool
+var accessibleFlag bool
+
+// setupAccessibility turns colors off with --no-color, NO_COLOR or TERM=dumb. The accessible mode
+// also turns colors off, prompts are then numbered lists and statuses are printed as plain lines, see setupPrompter.
+func setupAccessibility() {
+	if os.Getenv(accessibleEnv) != "" {
+		accessibleFlag = true
+	}
+	if noColorFlag || accessibleFlag || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		disableColors()
+	}
+}
+
+// disableColors turns off the colors of fatih/color and of the promptui templates and icons
+func disableColors() {
+	color.NoColor = true
+
+	for name := range promptui.FuncMap {
+		promptui.FuncMap[name] = func(v interface{}) string {
+			return fmt.Sprint(v)
+		}
+	}
+	promptui.IconInitial = "?"
+	promptui.IconGood = "✔"
+	promptui.IconWarn = "⚠"
+	promptui.IconBad = "✗"
+	promptui.IconSelect = "▸"
+}
+
+// plainStatus reports whether statuses are printed as a line per change instead of a redrawn table,
+// cursor movements only make sense on a terminal.
+func plainStatus() bool {
+	return accessibleFlag || os.Getenv("TERM") == "dumb" || !isTerminal(humanOut)
+}
+
+// isTerminal reports whether w writes to a terminal
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fd := file.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
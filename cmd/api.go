@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+)
+
+// defaultCacheTTL is how long project, branch and pipeline lists are cached when cache_ttl is not configured
+const defaultCacheTTL = 10 * time.Minute
+
+// cacheDir holds the cached Buddy API responses, in a directory per workspace
+var cacheDir = filepath.Join(configDir, "cache")
+
+var refreshFlag bool
+var recordFlag string
+var replayFlag string
+
+// apiTransport sends the Buddy API requests, setupTransport replaces it to record or replay them
+var apiTransport http.RoundTripper = http.DefaultTransport
+
+// setupTransport validates --record and --replay and sets up the matching transport
+func setupTransport() error {
+	if recordFlag != "" && replayFlag != "" {
+		return classify(errConfig, errors.New("--record and --replay can't be combined"))
+	}
+
+	if recordFlag != "" {
+		transport, err := buddy.NewRecordingTransport(http.DefaultTransport, recordFlag)
+		if err != nil {
+			return classify(errConfig, fmt.Errorf("unable to record to %s: %w", recordFlag, err))
+		}
+		apiTransport = transport
+	}
+	if replayFlag != "" {
+		transport, err := buddy.NewReplayTransport(replayFlag)
+		if err != nil {
+			return classify(errConfig, fmt.Errorf("unable to replay %s: %w", replayFlag, err))
+		}
+		apiTransport = transport
+	}
+	return nil
+}
+
+// newAPIClient returns a Buddy API client caching the project, branch and pipeline lists.
+// Read-only commands fall back to expired lists when the API can't be reached.
+// Recording and replaying bypass the cache, so every request is captured or served from the fixtures.
+func newAPIClient(config Config, readOnly bool) *buddy.CachedClient {
+	buddyClient := buddy.NewBuddyClient(config.Token, config.Workspace)
+	buddyClient.HTTPClient = &http.Client{Transport: &buddy.LoggingTransport{Base: apiTransport}}
+
+	client := buddy.NewCachedClient(buddyClient, cacheDir, cacheTTL(config))
+	client.Refresh = refreshFlag
+	client.AllowStale = readOnly
+	client.Disabled = recordFlag != "" || replayFlag != ""
+	client.OnStale = func(key string, age time.Duration) {
+		slog.Warn("Buddy is unreachable, using a cached response", "key", key, "age", age.Round(time.Second))
+	}
+	return client
+}
+
+// cacheTTL returns the configured cache TTL, or the default when it is missing or invalid
+func cacheTTL(config Config) time.Duration {
+	if config.CacheTTL == "" {
+		return defaultCacheTTL
+	}
+	ttl, err := time.ParseDuration(config.CacheTTL)
+	if err != nil {
+		return defaultCacheTTL
+	}
+	return ttl
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Plan describes a set of deploy steps and the order they have to run in
+type Plan struct {
+	// Parallel is the maximum number of steps running at the same time
+	Parallel int `yaml:"parallel,omitempty"`
+	// StopOnFailure stops starting new steps once a step fails, defaults to true
+	StopOnFailure *bool      `yaml:"stop_on_failure,omitempty"`
+	Steps         []PlanStep `yaml:"steps"`
+}
+
+// PlanStep is a single pipeline run in a plan. A step starts once every step it depends on succeeded.
+type PlanStep struct {
+	Name      string   `yaml:"name"`
+	Project   string   `yaml:"project"`
+	Branch    string   `yaml:"branch"`
+	Pipeline  string   `yaml:"pipeline"` // Pipeline name or ID
+	DependsOn []string `yaml:"depends_on,omitempty"`
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply [plan.yaml]",
+	Short: "Run a multi-step deploy plan",
+	Long: `This command runs the deploy steps described in a plan file. Each step names a project, branch and pipeline.
+Steps without dependencies run in parallel, a step listing other steps in depends_on waits until they succeeded.
+Once a step fails no new steps are started, unless stop_on_failure is set to false.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := requireConfig()
+		if err != nil {
+			return err
+		}
+
+		runHooksOrWarn(loadHooks(config).BeforeSelection, HookEvent{Hook: "before_selection", Command: "apply", Workspace: config.Workspace})
+
+		plan, err := loadPlan(args[0])
+		if err != nil {
+			return classify(errConfig, err)
+		}
+
+		if cmd.Flags().Changed("parallel") || plan.Parallel == 0 {
+			plan.Parallel = parallelFlag
+		}
+
+		apiClient := newAPIClient(config, false)
+
+		var targets []*deployTarget
+		for _, step := range plan.Steps {
+			target, err := resolvePlanStep(apiClient, config, step)
+			if err != nil {
+				return fmt.Errorf("step %s: %w", step.Name, err)
+			}
+			targets = append(targets, target)
+		}
+
+		printPlan(plan, targets)
+
+		return runTargetsConfirmed(apiClient, config, "apply", targets, func() {
+			runPlan(apiClient, config, "apply", plan, targets)
+		})
+	},
+}
+
+func init() {
+	applyCmd.Flags().IntVar(&parallelFlag, "parallel", 3, "Maximum number of steps running at the same time, overrides the plan")
+	applyCmd.Flags().BoolVar(&cancelOnInterruptFlag, "cancel-on-interrupt", false, "Cancel the execution instead of detaching when interrupted while waiting")
+	rootCmd.AddCommand(applyCmd)
+}
+
+// loadPlan reads a plan file and validates its steps and dependencies
+func loadPlan(path string) (Plan, error) {
+	var plan Plan
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return plan, err
+	}
+
+	err = yaml.Unmarshal(data, &plan)
+	if err != nil {
+		return plan, fmt.Errorf("failed to parse plan %s: %v", path, err)
+	}
+
+	if len(plan.Steps) == 0 {
+		return plan, fmt.Errorf("plan %s has no steps", path)
+	}
+
+	steps := map[string]bool{}
+	for i, step := range plan.Steps {
+		if step.Name == "" {
+			return plan, fmt.Errorf("step %d has no name", i+1)
+		}
+		if steps[step.Name] {
+			return plan, fmt.Errorf("step %s is defined more than once", step.Name)
+		}
+		if step.Project == "" || step.Branch == "" || step.Pipeline == "" {
+			return plan, fmt.Errorf("step %s needs a project, branch and pipeline", step.Name)
+		}
+		steps[step.Name] = true
+	}
+
+	for _, step := range plan.Steps {
+		for _, dependency := range step.DependsOn {
+			if !steps[dependency] {
+				return plan, fmt.Errorf("step %s depends on unknown step %s", step.Name, dependency)
+			}
+		}
+	}
+
+	if cycle := findPlanCycle(plan); len(cycle) > 0 {
+		return plan, fmt.Errorf("steps %s depend on each other", strings.Join(cycle, ", "))
+	}
+
+	return plan, nil
+}
+
+// findPlanCycle returns the steps that can never start because their dependencies form a cycle
+func findPlanCycle(plan Plan) []string {
+	remaining := map[string]int{}
+	dependents := map[string][]string{}
+	var ready []string
+	for _, step := range plan.Steps {
+		remaining[step.Name] = len(step.DependsOn)
+		for _, dependency := range step.DependsOn {
+			dependents[dependency] = append(dependents[dependency], step.Name)
+		}
+		if len(step.DependsOn) == 0 {
+			ready = append(ready, step.Name)
+		}
+	}
+
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		delete(remaining, name)
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	var cycle []string
+	for _, step := range plan.Steps {
+		if _, ok := remaining[step.Name]; ok {
+			cycle = append(cycle, step.Name)
+		}
+	}
+	return cycle
+}
+
+// resolvePlanStep looks up the project, branch and pipeline of a step and checks the protection rules
+func resolvePlanStep(apiClient buddy.BuddyAPI, config Config, step PlanStep) (*deployTarget, error) {
+	if _, err := apiClient.FetchProjectByName(step.Project); err != nil {
+		return nil, err
+	}
+	if _, err := apiClient.FetchBranchByName(step.Project, step.Branch); err != nil {
+		return nil, err
+	}
+
+	pipeline, err := findPipeline(apiClient, step.Project, step.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := apiClient.FetchLatestCommit(step.Project, step.Branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkProtection(config, *pipeline, step.Branch); err != nil {
+		return nil, refuseDeployment(newHistoryEntry("apply", config, step.Project, step.Branch, *pipeline, commit.Revision), err)
+	}
+	if !pipelineMatchesBranch(*pipeline, step.Branch) {
+		slog.Warn("branch does not match the refs of the pipeline", "project", step.Project, "branch", step.Branch, "pipeline", pipeline.Name, "refs", formatRefs(pipeline.Refs))
+	}
+
+	return &deployTarget{
+		Name:     step.Name,
+		Project:  step.Project,
+		Branch:   step.Branch,
+		Pipeline: *pipeline,
+		Revision: *commit,
+		Status:   "PENDING",
+	}, nil
+}
+
+// printPlan prints every step of the plan before confirmation
+func printPlan(plan Plan, targets []*deployTarget) {
+	cyan := color.New(color.FgCyan).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+
+	fmt.Fprintln(humanOut, bold("Deploy plan:"))
+	for i, target := range targets {
+		fmt.Fprintf(humanOut, "  %s: %s on %s at %s with %s(%d)", bold(target.Name), cyan(target.Project), cyan(target.Branch), cyan(shortRevision(target.Revision.Revision)), cyan(target.Pipeline.Name), target.Pipeline.ID)
+		if dependsOn := plan.Steps[i].DependsOn; len(dependsOn) > 0 {
+			fmt.Fprintf(humanOut, " after %s", strings.Join(dependsOn, ", "))
+		}
+		fmt.Fprintln(humanOut)
+	}
+}
+
+// runPlan starts every step once its dependencies succeeded, running at most plan.Parallel steps at a time.
+// Steps that never started are marked as skipped.
+func runPlan(apiClient buddy.BuddyAPI, config Config, command string, plan Plan, targets []*deployTarget) {
+	hooks := loadHooks(config)
+	ctx, stop := context.WithCancelCause(context.Background())
+	defer stop(nil)
+
+	parallel := max(plan.Parallel, 1)
+	stopOnFailure := plan.StopOnFailure == nil || *plan.StopOnFailure
+
+	byName := map[string]*deployTarget{}
+	remaining := map[string]int{}
+	dependents := map[string][]string{}
+	var ready []string
+	for i, step := range plan.Steps {
+		byName[step.Name] = targets[i]
+		remaining[step.Name] = len(step.DependsOn)
+		for _, dependency := range step.DependsOn {
+			dependents[dependency] = append(dependents[dependency], step.Name)
+		}
+		if len(step.DependsOn) == 0 {
+			ready = append(ready, step.Name)
+		}
+	}
+
+	var mu sync.Mutex
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		finished := make(chan *deployTarget)
+		running := 0
+		stopped := false
+
+		launch := func() {
+			for len(ready) > 0 && running < parallel && !stopped && ctx.Err() == nil {
+				target := byName[ready[0]]
+				ready = ready[1:]
+				running++
+				go func() {
+					executeTarget(ctx, apiClient, config, command, hooks, target, &mu)
+					finished <- target
+				}()
+			}
+		}
+
+		launch()
+		for running > 0 {
+			target := <-finished
+			running--
+
+			mu.Lock()
+			succeeded := target.Status == "SUCCESSFUL"
+			mu.Unlock()
+
+			if succeeded {
+				for _, dependent := range dependents[target.Name] {
+					remaining[dependent]--
+					if remaining[dependent] == 0 {
+						ready = append(ready, dependent)
+					}
+				}
+			} else if stopOnFailure {
+				stopped = true
+			}
+			launch()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, target := range targets {
+			if target.Status == "PENDING" {
+				target.Status = "SKIPPED"
+			}
+		}
+	}()
+
+	watchTargets(targets, &mu, done, stop)
+}
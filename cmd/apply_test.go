@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+)
+
+// fakeBuddy runs pipelines without Buddy. An execution finishes after a few status checks with the
+// status set for its project in failures, SUCCESSFUL otherwise. Only the calls made while running targets are implemented.
+type fakeBuddy struct {
+	buddy.BuddyAPI
+
+	mu       sync.Mutex
+	failures map[string]string
+	projects []string
+	polls    map[int]int
+	// events lists "start <project>" and "end <project>" in the order they happened
+	events     []string
+	running    int
+	maxRunning int
+}
+
+func newFakeBuddy(failures map[string]string) *fakeBuddy {
+	return &fakeBuddy{failures: failures, polls: map[int]int{}}
+}
+
+func (f *fakeBuddy) RunPipeline(project string, _ int, _, _ string) (*buddy.PipelineExecutionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.projects = append(f.projects, project)
+	f.events = append(f.events, "start "+project)
+	f.running++
+	f.maxRunning = max(f.maxRunning, f.running)
+	return &buddy.PipelineExecutionResponse{ID: len(f.projects), Status: "ENQUEUED"}, nil
+}
+
+func (f *fakeBuddy) CheckPipelineStatus(_ string, _ int, executionID int) (*string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls[executionID]++
+	status := "INPROGRESS"
+	if f.polls[executionID] == 20 {
+		project := f.projects[executionID-1]
+		status = "SUCCESSFUL"
+		if failure, ok := f.failures[project]; ok {
+			status = failure
+		}
+		f.events = append(f.events, "end "+project)
+		f.running--
+	}
+	return &status, nil
+}
+
+// index returns the position of event, or -1 when it never happened
+func (f *fakeBuddy) index(event string) int {
+	return slices.Index(f.events, event)
+}
+
+// runTestPlan runs every step of plan against apiClient and returns the targets by step name
+func runTestPlan(t *testing.T, apiClient buddy.BuddyAPI, plan Plan) map[string]*deployTarget {
+	t.Helper()
+	fastPolling(t)
+	humanOut = &bytes.Buffer{}
+	t.Cleanup(func() { humanOut = os.Stdout })
+
+	var targets []*deployTarget
+	byName := map[string]*deployTarget{}
+	for _, step := range plan.Steps {
+		target := &deployTarget{Name: step.Name, Project: step.Project, Branch: step.Branch, Pipeline: buddy.Pipeline{ID: 1, Name: step.Pipeline}, Status: "PENDING"}
+		targets = append(targets, target)
+		byName[step.Name] = target
+	}
+	runPlan(apiClient, Config{Workspace: "acme"}, "apply", plan, targets)
+	return byName
+}
+
+// step is a plan step deploying the project of the same name
+func step(name string, dependsOn ...string) PlanStep {
+	return PlanStep{Name: name, Project: name, Branch: "main", Pipeline: "Deploy", DependsOn: dependsOn}
+}
+
+func TestLoadPlanRejectsInvalidDependencies(t *testing.T) {
+	tests := map[string]struct {
+		plan string
+		want string
+	}{
+		"cycle": {
+			plan: "steps:\n" +
+				"  - {name: a, project: a, branch: main, pipeline: Deploy, depends_on: [c]}\n" +
+				"  - {name: b, project: b, branch: main, pipeline: Deploy, depends_on: [a]}\n" +
+				"  - {name: c, project: c, branch: main, pipeline: Deploy, depends_on: [b]}\n" +
+				"  - {name: d, project: d, branch: main, pipeline: Deploy}\n",
+			want: "steps a, b, c depend on each other",
+		},
+		"self dependency": {
+			plan: "steps:\n  - {name: a, project: a, branch: main, pipeline: Deploy, depends_on: [a]}\n",
+			want: "steps a depend on each other",
+		},
+		"unknown dependency": {
+			plan: "steps:\n  - {name: a, project: a, branch: main, pipeline: Deploy, depends_on: [missing]}\n",
+			want: "step a depends on unknown step missing",
+		},
+	}
+	for name, test := range tests {
+		path := filepath.Join(t.TempDir(), "plan.yaml")
+		if err := os.WriteFile(path, []byte(test.plan), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadPlan(path); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: loadPlan() err = %v, want %q", name, err, test.want)
+		}
+	}
+}
+
+func TestRunPlanWaitsForDependencies(t *testing.T) {
+	fake := newFakeBuddy(nil)
+	targets := runTestPlan(t, fake, Plan{Parallel: 3, Steps: []PlanStep{step("c", "a", "b"), step("b", "a"), step("a")}})
+
+	for name, target := range targets {
+		if target.Status != "SUCCESSFUL" {
+			t.Errorf("step %s is %s, want SUCCESSFUL", name, target.Status)
+		}
+	}
+	if fake.index("start b") < fake.index("end a") {
+		t.Errorf("b started before a finished: %v", fake.events)
+	}
+	if fake.index("start c") < fake.index("end a") || fake.index("start c") < fake.index("end b") {
+		t.Errorf("c started before a and b finished: %v", fake.events)
+	}
+}
+
+func TestRunPlanStopOnFailure(t *testing.T) {
+	stop := true
+	fake := newFakeBuddy(map[string]string{"a": "FAILED"})
+	targets := runTestPlan(t, fake, Plan{Parallel: 1, StopOnFailure: &stop, Steps: []PlanStep{step("a"), step("b"), step("c", "a")}})
+
+	want := map[string]string{"a": "FAILED", "b": "SKIPPED", "c": "SKIPPED"}
+	for name, status := range want {
+		if targets[name].Status != status {
+			t.Errorf("step %s is %s, want %s", name, targets[name].Status, status)
+		}
+	}
+	if fake.index("start b") >= 0 {
+		t.Errorf("b started after a failed: %v", fake.events)
+	}
+}
+
+func TestRunPlanContinueOnFailure(t *testing.T) {
+	stop := false
+	fake := newFakeBuddy(map[string]string{"a": "FAILED"})
+	targets := runTestPlan(t, fake, Plan{Parallel: 1, StopOnFailure: &stop, Steps: []PlanStep{step("a"), step("b"), step("c", "a")}})
+
+	// Steps depending on a failed step never start, whatever stop_on_failure is
+	want := map[string]string{"a": "FAILED", "b": "SUCCESSFUL", "c": "SKIPPED"}
+	for name, status := range want {
+		if targets[name].Status != status {
+			t.Errorf("step %s is %s, want %s", name, targets[name].Status, status)
+		}
+	}
+}
+
+func TestRunPlanParallelLimit(t *testing.T) {
+	fake := newFakeBuddy(nil)
+	targets := runTestPlan(t, fake, Plan{Parallel: 2, Steps: []PlanStep{step("a"), step("b"), step("c"), step("d"), step("e")}})
+
+	for name, target := range targets {
+		if target.Status != "SUCCESSFUL" {
+			t.Errorf("step %s is %s, want SUCCESSFUL", name, target.Status)
+		}
+	}
+	if fake.maxRunning != 2 {
+		t.Errorf("%d steps ran at the same time, want 2", fake.maxRunning)
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/JacobAndrewSmith92/gobuddy/internal/util"
+	"github.com/fatih/color"
+)
+
+// largeDiffLines is the number of changed lines from which a changelog is flagged as a large diff
+const largeDiffLines = 1000
+
+// largeDiffFiles is the number of changed files from which a changelog is flagged as a large diff
+const largeDiffFiles = 50
+
+// migrationPatterns are path or subject fragments that suggest a database migration
+var migrationPatterns = []string{"migration", "migrate", "schema", ".sql"}
+
+// changelog lists what a deployment ships compared to the last successful execution of the pipeline
+type changelog struct {
+	Base    string
+	Commits []util.Commit
+	Changes []util.FileChange
+	Source  string
+}
+
+// showChangelog prints the commits between the last successful execution of the pipeline and the revision
+// about to be deployed. The local repository is used when it has both commits, the Buddy API otherwise.
+// Failing to build the changelog only prints a warning.
+func showChangelog(apiClient buddy.BuddyAPI, project string, pipeline buddy.Pipeline, revision string) {
+	executions, err := apiClient.FetchExecutions(project, pipeline.ID)
+	if err != nil {
+		slog.Warn("unable to fetch the changelog", "error", err)
+		return
+	}
+
+	last := lastSuccessfulExecution(executions)
+	if last == nil || last.ToRevision.Revision == "" {
+		slog.Info("No successful execution yet, this is the first deployment", "pipeline", pipeline.Name)
+		return
+	}
+	if last.ToRevision.Revision == revision {
+		slog.Warn("revision is already deployed", "revision", shortRevision(revision), "pipeline", pipeline.Name)
+		return
+	}
+
+	changes, err := buildChangelog(apiClient, project, last.ToRevision.Revision, revision)
+	if err != nil {
+		slog.Warn("unable to fetch the changelog", "error", err)
+		return
+	}
+
+	printChangelog(*changes)
+}
+
+// buildChangelog collects the commits and file changes between base and head
+func buildChangelog(apiClient buddy.BuddyAPI, project, base, head string) (*changelog, error) {
+	if util.HasCommit(base) && util.HasCommit(head) {
+		commits, err := util.GetCommitsBetween(base, head)
+		if err == nil {
+			files, err := util.GetChangedFiles(base, head)
+			if err == nil {
+				return &changelog{Base: base, Commits: commits, Changes: files, Source: "local git"}, nil
+			}
+		}
+	}
+
+	comparison, err := apiClient.CompareRevisions(project, base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &changelog{Base: base, Source: "Buddy"}
+	for _, commit := range comparison.Commits {
+		author := commit.Committer.Name
+		if author == "" {
+			author = commit.Author.Email
+		}
+		changes.Commits = append(changes.Commits, util.Commit{SHA: commit.Revision, Author: author, Subject: firstLine(commit.Message)})
+	}
+	for _, change := range comparison.Changes {
+		changes.Changes = append(changes.Changes, util.FileChange{Path: change.FileName, Additions: change.Additions, Deletions: change.Deletions})
+	}
+	return changes, nil
+}
+
+// printChangelog prints the commits of a changelog and flags migrations and large diffs
+func printChangelog(changes changelog) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fmt.Fprintln(humanOut, bold(fmt.Sprintf("Changes since %s (%d commits, from %s):", shortRevision(changes.Base), len(changes.Commits), changes.Source)))
+	for _, commit := range changes.Commits {
+		fmt.Fprintf(humanOut, "  %s %s %s\n", cyan(shortRevision(commit.SHA)), commit.Subject, color.New(color.Faint).Sprintf("(%s)", commit.Author))
+	}
+
+	var migrations []string
+	lines := 0
+	for _, change := range changes.Changes {
+		lines += change.Additions + change.Deletions
+		if looksLikeMigration(change.Path) {
+			migrations = append(migrations, change.Path)
+		}
+	}
+	for _, commit := range changes.Commits {
+		if looksLikeMigration(commit.Subject) {
+			migrations = append(migrations, fmt.Sprintf("commit %s: %s", shortRevision(commit.SHA), commit.Subject))
+		}
+	}
+
+	if len(migrations) > 0 {
+		fmt.Fprintln(humanOut, yellow("Warning: this deployment looks like it contains migrations:"))
+		for _, migration := range migrations {
+			fmt.Fprintf(humanOut, "  %s\n", yellow(migration))
+		}
+	}
+	if lines >= largeDiffLines || len(changes.Changes) >= largeDiffFiles {
+		fmt.Fprintln(humanOut, yellow(fmt.Sprintf("Warning: large diff, %d lines changed in %d files", lines, len(changes.Changes))))
+	}
+}
+
+// looksLikeMigration reports whether a path or commit subject mentions a migration
+func looksLikeMigration(text string) bool {
+	text = strings.ToLower(text)
+	for _, pattern := range migrationPatterns {
+		if strings.Contains(text, pattern) {
+			return true
+		}
+	}
+	return false
+}
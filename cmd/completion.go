@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/spf13/cobra"
+)
+
+// completionClient returns an API client for completions. Completions are served from the response cache
+// and fall back to expired lists silently, tab completion must never print warnings.
+func completionClient(config Config) buddy.BuddyAPI {
+	apiClient := newAPIClient(config, true)
+	apiClient.OnStale = nil
+	return apiClient
+}
+
+// completeProjects completes project names, skipping the projects already given
+func completeProjects(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projects, err := completionClient(config).FetchProjects()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, project := range projects {
+		if !slices.Contains(args, project.Name) {
+			completions = append(completions, project.Name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFirstProject completes the project of commands taking a single project argument
+func completeFirstProject(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeProjects(cmd, args, toComplete)
+}
+
+// completeBranches completes the branches of the project given as first argument
+func completeBranches(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	config, err := loadConfig()
+	if err != nil || len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	branches, err := completionClient(config).FetchBranches(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, branch := range branches {
+		completions = append(completions, branch.Name)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePipelines completes the pipeline IDs of the project given as first argument, described by their names
+func completePipelines(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	config, err := loadConfig()
+	if err != nil || len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	pipelines, err := completionClient(config).FetchPipelines(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, pipeline := range pipelines {
+		completions = append(completions, strconv.Itoa(pipeline.ID)+"\t"+pipeline.Name)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGroups completes the project groups of the configuration
+func completeGroups(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var groups []string
+	for name, projects := range config.Groups {
+		groups = append(groups, name+"\t"+strings.Join(projects, ", "))
+	}
+	slices.Sort(groups)
+	return groups, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDeployTargets completes projects, or presets once the argument starts with @
+func completeDeployTargets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !strings.HasPrefix(toComplete, presetPrefix) {
+		return completeProjects(cmd, args, toComplete)
+	}
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var presets []string
+	for name, preset := range config.Presets {
+		presets = append(presets, presetPrefix+name+"\t"+describePreset(preset))
+	}
+	slices.Sort(presets)
+	return presets, cobra.ShellCompDirectiveNoFileComp
+}
@@ -3,13 +3,13 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
-	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +17,32 @@ type Config struct {
 	Token     string    `json:"token"`
 	Workspace string    `json:"workspace"`
 	Protected Protected `json:"protected,omitempty"`
+	// Groups maps a group name to the projects deployed together, e.g. "release": ["api", "web"]
+	Groups map[string][]string `json:"groups,omitempty"`
+	// SoakTime is how old a successful execution may be and still get promoted, e.g. "24h"
+	SoakTime string `json:"soak_time,omitempty"`
+	// Remote is the git remote branches are pushed to before deploying them, defaults to "origin"
+	Remote string `json:"remote,omitempty"`
+	// Webhooks are notified once an execution started by gobuddy finished
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+	// Hooks are shell commands run during a deploy, see Hooks
+	Hooks Hooks `json:"hooks,omitempty"`
+	// CacheTTL is how long project, branch and pipeline lists are cached, e.g. "10m". "0s" always revalidates them
+	CacheTTL string `json:"cache_ttl,omitempty"`
+	// Presets are named deploys, run as `gobuddy deploy @name` or `gobuddy name`, see Preset
+	Presets map[string]Preset `json:"presets,omitempty"`
+}
+
+// Webhook is a URL a deploy notification is posted to
+type Webhook struct {
+	URL string `json:"url"`
+	// Template is the payload format, "json" (default) or "slack"
+	Template string `json:"template,omitempty"`
+}
+
+// ConfigReset is the output of config reset
+type ConfigReset struct {
+	Reset bool `json:"reset"`
 }
 
 type Protected struct {
@@ -26,6 +52,9 @@ type Protected struct {
 
 var configFilePath = filepath.Join(os.Getenv("HOME"), ".gobuddy_config.json")
 
+// configDir holds the files gobuddy writes besides the configuration, like the deployment history
+var configDir = filepath.Join(os.Getenv("HOME"), ".gobuddy")
+
 // configCmd represents the config command
 var configCmd = &cobra.Command{
 	Use:   "config",
@@ -34,12 +63,12 @@ var configCmd = &cobra.Command{
 }
 
 var configSetCmd = &cobra.Command{
-	Use:   "set [token|workspace|protected.*] [value]",
+	Use:   "set [token|workspace|protected.*|soak_time|remote|cache_ttl|group.<name>|webhook.<json|slack>] [value]",
 	Short: "Set or update your configuration",
-	Long:  `This subcommand allows you to set or update your authorization token, workspace, and a protected branch and pipeline. Pass "token", "workspace", "protected_pipeline" or "protected_branch" followed by the value to update.`,
+	Long:  `This subcommand allows you to set or update your authorization token, workspace, and a protected branch and pipeline. Pass "token", "workspace", "protected_pipeline", "protected_branch", "soak_time", "remote" or "cache_ttl" followed by the value to update. Pass "group.<name>" followed by a comma separated list of projects to define a project group, or "webhook.json" or "webhook.slack" followed by a URL to notify it when a deployment finished.`,
 	Args:  cobra.MinimumNArgs(0), // No minimum args; prompts if args are missing
-	Run: func(_ *cobra.Command, args []string) {
-		setConfigFromArgs(args)
+	RunE: func(_ *cobra.Command, args []string) error {
+		return setConfigFromArgs(args)
 	},
 }
 
@@ -47,23 +76,15 @@ var configGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get the current configuration",
 	Long:  `This subcommand will display the currently saved token and workspace.`,
-	Run: func(_ *cobra.Command, _ []string) {
+	RunE: func(_ *cobra.Command, _ []string) error {
 		config, err := loadConfig()
 		if err != nil && os.IsNotExist(err) {
-			handleMissingConfig()
-			return
+			return handleMissingConfig()
 		} else if err != nil {
-			log.Fatalf("Failed to load configuration: %v\n", err)
+			return classify(errConfig, fmt.Errorf("failed to load configuration: %w", err))
 		}
 
-		cyan := color.New(color.FgCyan).SprintFunc()
-		bold := color.New(color.Bold).SprintFunc()
-
-		fmt.Println(bold("Current Configuration:"))
-		fmt.Printf("Token: %s\n", cyan(config.Token))
-		fmt.Printf("Workspace: %s\n", cyan(config.Workspace))
-		fmt.Printf("Protected Branch: %s\n", cyan(config.Protected.Branch))
-		fmt.Printf("Protected Pipeline: %s\n", cyan(config.Protected.Pipeline))
+		return printData(config, func() { printConfig(config) })
 	},
 }
 
@@ -71,8 +92,8 @@ var configResetCmd = &cobra.Command{
 	Use:   "reset",
 	Short: "Reset the current configuration",
 	Long:  `This subcommand will clear the currently saved configuration.`,
-	Run: func(_ *cobra.Command, _ []string) {
-		confirmReset()
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return confirmReset()
 	},
 }
 
@@ -83,17 +104,42 @@ func init() {
 	rootCmd.AddCommand(configCmd)
 }
 
+// printConfig prints the configuration for humans
+func printConfig(config Config) {
+	cyan := color.New(color.FgCyan).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+
+	fmt.Fprintln(humanOut, bold("Current Configuration:"))
+	fmt.Fprintf(humanOut, "Token: %s\n", cyan(config.Token))
+	fmt.Fprintf(humanOut, "Workspace: %s\n", cyan(config.Workspace))
+	fmt.Fprintf(humanOut, "Protected Branch: %s\n", cyan(config.Protected.Branch))
+	fmt.Fprintf(humanOut, "Protected Pipeline: %s\n", cyan(config.Protected.Pipeline))
+	fmt.Fprintf(humanOut, "Soak Time: %s\n", cyan(config.SoakTime))
+	fmt.Fprintf(humanOut, "Remote: %s\n", cyan(config.Remote))
+	fmt.Fprintf(humanOut, "Cache TTL: %s\n", cyan(cacheTTL(config)))
+	for _, webhook := range config.Webhooks {
+		fmt.Fprintf(humanOut, "Webhook (%s): %s\n", webhookTemplate(webhook), cyan(webhook.URL))
+	}
+	for name, projects := range config.Groups {
+		fmt.Fprintf(humanOut, "Group %s: %s\n", name, cyan(strings.Join(projects, ", ")))
+	}
+	for name, preset := range config.Presets {
+		fmt.Fprintf(humanOut, "Preset %s: %s\n", name, cyan(describePreset(preset)))
+	}
+}
+
 // Save the configuration
-func saveConfig(config Config) {
+func saveConfig(config Config) error {
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		log.Fatalf("Failed to marshal config: %v\n", err)
+		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
 	err = os.WriteFile(configFilePath, data, 0600)
 	if err != nil {
-		log.Fatalf("Failed to write config file: %v\n", err)
+		return classify(errConfig, fmt.Errorf("failed to write config file: %w", err))
 	}
+	return nil
 }
 
 // Load the configuration
@@ -113,40 +159,44 @@ func loadConfig() (Config, error) {
 	return config, nil
 }
 
+// requireConfig loads the configuration commands talking to Buddy can't work without.
+// Replays don't talk to Buddy, they work without a configuration.
+func requireConfig() (Config, error) {
+	config, err := loadConfig()
+	if os.IsNotExist(err) && replayFlag != "" {
+		return Config{Workspace: "replay"}, nil
+	} else if os.IsNotExist(err) {
+		return config, classify(errConfig, fmt.Errorf("no configuration found, run 'gobuddy config set' to create one"))
+	} else if err != nil {
+		return config, classify(errConfig, fmt.Errorf("failed to load configuration: %w", err))
+	}
+	return config, nil
+}
+
 // Handle case where config doesn't exist during 'config get'
-func handleMissingConfig() {
+func handleMissingConfig() error {
 	red := color.New(color.FgRed).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
 
-	fmt.Println(red("No configuration found."))
-
-	prompt := promptui.Prompt{
-		Label: yellow("Would you like to create one? (yes/no)"),
-		Validate: func(input string) error {
-			if input != "yes" && input != "no" {
-				return fmt.Errorf("please type 'yes' or 'no'")
-			}
-			return nil
-		},
-	}
+	fmt.Fprintln(humanOut, red("No configuration found."))
 
-	result, err := prompt.Run()
+	create, err := prompter.Confirm(yellow("Would you like to create one?"))
 	if err != nil {
-		log.Fatalf("Prompt failed %v\n", err)
+		return err
 	}
 
-	if result == "yes" {
-		setConfig("", "", "", "")
-	} else {
-		fmt.Println("No configuration created.")
+	if create {
+		return setConfig("", "", "", "")
 	}
+	fmt.Fprintln(humanOut, "No configuration created.")
+	return nil
 }
 
 // Set or update configuration fields from arguments
-func setConfigFromArgs(args []string) {
+func setConfigFromArgs(args []string) error {
 	config, err := loadConfig()
 	if err != nil && !os.IsNotExist(err) {
-		log.Fatalf("Failed to load existing config: %v\n", err)
+		return classify(errConfig, fmt.Errorf("failed to load existing config: %w", err))
 	}
 
 	yellow := color.New(color.FgYellow).SprintFunc()
@@ -160,36 +210,118 @@ func setConfigFromArgs(args []string) {
 		switch key {
 		case "token":
 			config.Token = value
-			fmt.Printf("Token updated to: %s\n", yellow(value))
+			fmt.Fprintf(humanOut, "Token updated to: %s\n", yellow(value))
 		case "workspace":
 			config.Workspace = value
-			fmt.Printf("Workspace updated to: %s\n", yellow(value))
+			fmt.Fprintf(humanOut, "Workspace updated to: %s\n", yellow(value))
 		case "protected_pipeline":
 			config.Protected.Pipeline = value
-			fmt.Printf("Protected Pipeline updated to: %s\n", yellow(value))
+			fmt.Fprintf(humanOut, "Protected Pipeline updated to: %s\n", yellow(value))
 		case "protected_branch":
 			config.Protected.Branch = value
-			fmt.Printf("Protected Branch updated to: %s\n", yellow(value))
+			fmt.Fprintf(humanOut, "Protected Branch updated to: %s\n", yellow(value))
+		case "remote":
+			config.Remote = value
+			fmt.Fprintf(humanOut, "Remote updated to: %s\n", yellow(value))
+		case "cache_ttl":
+			if _, err := time.ParseDuration(value); err != nil {
+				return classify(errConfig, fmt.Errorf("invalid cache TTL %s: %w", value, err))
+			}
+			config.CacheTTL = value
+			fmt.Fprintf(humanOut, "Cache TTL updated to: %s\n", yellow(value))
+		case "soak_time":
+			if _, err := time.ParseDuration(value); err != nil {
+				return classify(errConfig, fmt.Errorf("invalid soak time %s: %w", value, err))
+			}
+			config.SoakTime = value
+			fmt.Fprintf(humanOut, "Soak Time updated to: %s\n", yellow(value))
 		default:
-			log.Fatalf("Invalid argument: %s. Use 'token' or 'workspace'.", key)
+			if name, ok := strings.CutPrefix(key, "group."); ok && name != "" {
+				setGroup(&config, name, value)
+				break
+			}
+			if name, ok := strings.CutPrefix(key, "preset."); ok && name != "" {
+				if err := setPreset(&config, name, value); err != nil {
+					return err
+				}
+				break
+			}
+			if template, ok := strings.CutPrefix(key, "webhook."); ok && (template == "json" || template == "slack") {
+				setWebhook(&config, template, value)
+				break
+			}
+			return classify(errConfig, fmt.Errorf("invalid argument: %s. Use 'token' or 'workspace'", key))
 		}
 	} else if len(args) == 0 {
 		// Prompt for both token and workspace if no args are provided
-		setConfig("", "", "", "")
-		return
+		return setConfig("", "", "", "")
 	} else {
-		log.Fatalf("Invalid number of arguments. You must provide a key (token|workspace) and a value.")
+		return classify(errConfig, fmt.Errorf("invalid number of arguments. You must provide a key (token|workspace) and a value"))
+	}
+
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+	fmt.Fprintln(humanOut, green("Configuration updated successfully!"))
+	return printData(config, nil)
+}
+
+// setGroup stores a comma separated list of projects under a group name.
+// An empty list removes the group.
+func setGroup(config *Config, name, value string) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	var projects []string
+	for _, project := range strings.Split(value, ",") {
+		if project = strings.TrimSpace(project); project != "" {
+			projects = append(projects, project)
+		}
+	}
+
+	if len(projects) == 0 {
+		delete(config.Groups, name)
+		fmt.Fprintf(humanOut, "Group %s removed\n", yellow(name))
+		return
+	}
+
+	if config.Groups == nil {
+		config.Groups = map[string][]string{}
+	}
+	config.Groups[name] = projects
+	fmt.Fprintf(humanOut, "Group %s updated to: %s\n", yellow(name), yellow(strings.Join(projects, ", ")))
+}
+
+// setWebhook adds a webhook with the given template. An empty URL removes every webhook using the template.
+func setWebhook(config *Config, template, url string) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	if url == "" {
+		var webhooks []Webhook
+		for _, webhook := range config.Webhooks {
+			if webhookTemplate(webhook) != template {
+				webhooks = append(webhooks, webhook)
+			}
+		}
+		config.Webhooks = webhooks
+		fmt.Fprintf(humanOut, "Removed %s webhooks\n", yellow(template))
+		return
 	}
 
-	saveConfig(config)
-	fmt.Println(green("Configuration updated successfully!"))
+	for _, webhook := range config.Webhooks {
+		if webhook.URL == url && webhookTemplate(webhook) == template {
+			fmt.Fprintf(humanOut, "Webhook %s already configured\n", yellow(url))
+			return
+		}
+	}
+	config.Webhooks = append(config.Webhooks, Webhook{URL: url, Template: template})
+	fmt.Fprintf(humanOut, "Added %s webhook: %s\n", yellow(template), yellow(url))
 }
 
 // Prompt-based configuration setup
-func setConfig(tokenFlag, workspaceFlag, protectedBranchFlag, protectedPipelineFlag string) {
+func setConfig(tokenFlag, workspaceFlag, protectedBranchFlag, protectedPipelineFlag string) error {
 	config, err := loadConfig()
 	if err != nil && !os.IsNotExist(err) {
-		log.Fatalf("Failed to load existing config: %v\n", err)
+		return classify(errConfig, fmt.Errorf("failed to load existing config: %w", err))
 	}
 
 	yellow := color.New(color.FgYellow).SprintFunc()
@@ -197,20 +329,14 @@ func setConfig(tokenFlag, workspaceFlag, protectedBranchFlag, protectedPipelineF
 
 	// Prompt for token if not provided
 	if tokenFlag == "" {
-		log.Printf("Current Token: %s\n", config.Token)
-		tokenPrompt := promptui.Prompt{
-			Label:   yellow("Enter your Buddy API token. (Press enter to skip)"),
-			Mask:    '*',
-			Default: config.Token,
-		}
-		token, err := tokenPrompt.Run()
+		slog.Info("Current token", "token", config.Token)
+		token, err := prompter.Input(yellow("Enter your Buddy API token. (Press enter to skip)"), config.Token, true)
 		if err != nil {
-			log.Fatalf("Failed to read token: %v\n", err)
+			return fmt.Errorf("failed to read token: %w", err)
 		}
 
 		if token == "" {
-			log.Println(`Looks like you didn't provide a token. Need one?`)
-			log.Println(`Here's how to create one: https://buddy.works/docs/api/getting-started/oauth2/personal-access-token`)
+			slog.Info("Looks like you didn't provide a token. Need one? Here's how to create one", "url", "https://buddy.works/docs/api/getting-started/oauth2/personal-access-token")
 		} else {
 			config.Token = token
 		}
@@ -218,74 +344,57 @@ func setConfig(tokenFlag, workspaceFlag, protectedBranchFlag, protectedPipelineF
 
 	// Prompt for workspace if not provided
 	if workspaceFlag == "" {
-		log.Printf("Current Workspace: %s\n", config.Workspace)
-		workspacePrompt := promptui.Prompt{
-			Label:   yellow("Enter your Buddy workspace"),
-			Default: config.Workspace,
-		}
-		workspace, err := workspacePrompt.Run()
+		slog.Info("Current workspace", "workspace", config.Workspace)
+		workspace, err := prompter.Input(yellow("Enter your Buddy workspace"), config.Workspace, false)
 		if err != nil {
-			log.Fatalf("Failed to read workspace: %v\n", err)
+			return fmt.Errorf("failed to read workspace: %w", err)
 		}
 		config.Workspace = workspace
 	}
 
 	if protectedBranchFlag == "" {
-		log.Printf("Current Protected Branch: %s\n", config.Protected.Branch)
-		branchPrompt := promptui.Prompt{
-			Label:   yellow("Enter the branch you want to protect"),
-			Default: config.Protected.Branch,
-		}
-		branch, err := branchPrompt.Run()
+		slog.Info("Current protected branch", "branch", config.Protected.Branch)
+		branch, err := prompter.Input(yellow("Enter the branch you want to protect"), config.Protected.Branch, false)
 		if err != nil {
-			log.Fatalf("Failed to read branch: %v\n", err)
+			return fmt.Errorf("failed to read branch: %w", err)
 		}
 		config.Protected.Branch = branch
 	}
 
 	if protectedPipelineFlag == "" {
-		log.Printf("Current Protected Pipeline: %s\n", config.Protected.Pipeline)
-		pipelinePrompt := promptui.Prompt{
-			Label:   yellow("Enter the pipeline you want to protect"),
-			Default: config.Protected.Pipeline,
-		}
-		pipeline, err := pipelinePrompt.Run()
+		slog.Info("Current protected pipeline", "pipeline", config.Protected.Pipeline)
+		pipeline, err := prompter.Input(yellow("Enter the pipeline you want to protect"), config.Protected.Pipeline, false)
 		if err != nil {
-			log.Fatalf("Failed to read pipeline: %v\n", err)
+			return fmt.Errorf("failed to read pipeline: %w", err)
 		}
 		config.Protected.Pipeline = pipeline
 	}
 
-	saveConfig(config)
-	fmt.Println(green("Configuration saved successfully!"))
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+	fmt.Fprintln(humanOut, green("Configuration saved successfully!"))
+	return printData(config, nil)
 }
 
 // Confirm reset
-func confirmReset() {
-	confirm := promptui.Prompt{
-		Label: "Are you sure you want to reset the configuration? (yes/no)",
-		Validate: func(input string) error {
-			if input != "yes" && input != "no" {
-				return fmt.Errorf("please type 'yes' or 'no'")
-			}
-			return nil
-		},
-	}
-
-	result, err := confirm.Run()
+func confirmReset() error {
+	reset, err := prompter.Confirm("Are you sure you want to reset the configuration?")
 	if err != nil {
-		log.Fatalf("Prompt failed %v\n", err)
+		return err
 	}
 
-	if result == "yes" {
+	if reset {
 		err := os.Remove(configFilePath)
 		if err != nil {
-			log.Fatalf("Failed to reset configuration: %v\n", err)
+			return classify(errConfig, fmt.Errorf("failed to reset configuration: %w", err))
 		}
 
 		green := color.New(color.FgGreen).SprintFunc()
-		fmt.Println(green("Configuration has been reset."))
+		fmt.Fprintln(humanOut, green("Configuration has been reset."))
 	} else {
-		fmt.Println("Reset canceled.")
+		fmt.Fprintln(humanOut, "Reset canceled.")
 	}
+
+	return printData(ConfigReset{Reset: reset}, nil)
 }
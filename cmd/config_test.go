@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfigSetInteractive(t *testing.T) {
+	useHome(t)
+	writeConfig(t, Config{Token: "old", Workspace: "acme"})
+
+	// An empty answer keeps the current value
+	answers := []string{"new-token", "", "main", "Deploy to Production"}
+	if _, err := runGobuddy(t, answers, "config", "set"); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{Token: "new-token", Workspace: "acme", Protected: Protected{Branch: "main", Pipeline: "Deploy to Production"}}
+	if config.Token != want.Token || config.Workspace != want.Workspace || config.Protected != want.Protected {
+		t.Errorf("config = %+v, want %+v", config, want)
+	}
+}
+
+func TestConfigGetCreatesMissingConfig(t *testing.T) {
+	useHome(t)
+
+	answers := []string{"yes", "token", "acme", "", ""}
+	if _, err := runGobuddy(t, answers, "config", "get"); err != nil {
+		t.Fatalf("config get failed: %v", err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Token != "token" || config.Workspace != "acme" {
+		t.Errorf("config = %+v, want token and acme", config)
+	}
+}
+
+func TestConfigGetDeclined(t *testing.T) {
+	useHome(t)
+
+	if _, err := runGobuddy(t, []string{"no"}, "config", "get"); err != nil {
+		t.Fatalf("config get failed: %v", err)
+	}
+	if _, err := os.Stat(configFilePath); !os.IsNotExist(err) {
+		t.Errorf("a configuration was created: %v", err)
+	}
+}
+
+func TestConfigSetPreset(t *testing.T) {
+	useHome(t)
+	writeConfig(t, Config{Token: "token", Workspace: "acme"})
+
+	if _, err := runGobuddy(t, nil, "config", "set", "preset.ship-api", "project=api,pipeline=Deploy to Staging,branch=current,wait=true"); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Preset{Project: "api", Pipeline: "Deploy to Staging", Branch: "current", Wait: true}
+	if config.Presets["ship-api"] != want {
+		t.Errorf("preset = %+v, want %+v", config.Presets["ship-api"], want)
+	}
+
+	_, err = runGobuddy(t, nil, "config", "set", "preset.broken", "project=api,color=blue")
+	if code := exitCode(err); code != exitConfig {
+		t.Errorf("exit code = %d (%v), want %d", code, err, exitConfig)
+	}
+}
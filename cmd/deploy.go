@@ -8,15 +8,18 @@ The configuration is stored in a JSON file located at ~/.gobuddy_config.json.
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os/signal"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
 	"github.com/JacobAndrewSmith92/gobuddy/internal/util"
-	"github.com/fatih/color"
-	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 )
 
@@ -27,160 +30,184 @@ import (
 var branchFlag string
 var pipelineFlag string
 var currentFlag bool
+var groupFlag string
+var parallelFlag int
+var dryRunFlag bool
+var pushFlag bool
+var waitFlag bool
 
 // deployCmd represents the deploy command
 var deployCmd = &cobra.Command{
-	Use:   "deploy [project]",
+	Use:   "deploy [project...]",
 	Short: "Select a project, branch, and pipeline for deployment",
-	Long:  `This command allows you to choose a project, a git branch, and a pipeline for deployment. The project can be provided as an argument, and the branch or pipeline can be provided via flags or interactively selected.`,
-	Args:  cobra.MaximumNArgs(1), // Accept one optional argument for project
-	Run: func(_ *cobra.Command, args []string) {
+	Long: `This command allows you to choose a project, a git branch, and a pipeline for deployment. The project can be provided as an argument, and the branch or pipeline can be provided via flags or interactively selected.
+Passing several projects, or a project group with --group, triggers the same pipeline on each of them.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var project, branch string
 		var pipeline buddy.Pipeline
-		config, err := loadConfig()
+		config, err := requireConfig()
 		if err != nil {
-			log.Fatalf("Failed to load configuration: %v\n", err)
+			return err
 		}
 
-		apiClient := buddy.NewBuddyClient(config.Token, config.Workspace)
-
+		args, err = applyPreset(cmd, config, args)
 		if err != nil {
-			log.Fatalf("Error creating api client: %v", err)
+			return err
+		}
+
+		apiClient := newAPIClient(config, dryRunFlag)
+
+		if groupFlag != "" {
+			group, ok := config.Groups[groupFlag]
+			if !ok {
+				return classify(errConfig, fmt.Errorf("project group %s not found in configuration", groupFlag))
+			}
+			args = append(args, group...)
+		}
+		args = uniqueProjects(args)
+
+		selection := HookEvent{Hook: "before_selection", Command: "deploy", Workspace: config.Workspace}
+		if len(args) == 1 {
+			selection.Project = args[0]
+		}
+		if !dryRunFlag {
+			runHooksOrWarn(loadHooks(config).BeforeSelection, selection)
+		}
+
+		if len(args) > 1 {
+			return deployMany(apiClient, config, args)
 		}
 
 		if currentFlag {
 			branch, project, err = util.GetBranchAndDirectory()
 			if err != nil {
-				log.Fatalf("Error: %v", err)
+				return err
 			}
-			log.Printf("Using current project %s and branch: %s\n", project, branch)
+			slog.Info("Using current project and branch", "project", project, "branch", branch)
 		}
 
+		// Branches and pipelines are fetched in the background as soon as the project is known,
+		// or guessed, while the project is looked up or picked
+		needBranches := branch == "" && branchFlag == ""
+		needPipelines := pipelineFlag == ""
+		var prefetch *projectPrefetch
+
 		if len(args) > 0 || (currentFlag && project != "") {
-			if project == "" {
+			if len(args) > 0 {
 				project = args[0]
 			}
-			fmt.Printf("Looking up project: %s\n", project)
+			prefetch = prefetchProject(apiClient, project, needBranches, needPipelines)
+			slog.Info("Looking up project", "project", project)
 			projectFound, err := apiClient.FetchProjectByName(project)
 			if err != nil {
-				log.Fatalf("Error: %v", err)
+				return err
 			}
-			log.Println("Project found.", project)
+			slog.Debug("Project found", "project", projectFound.Name)
 			project = projectFound.Name
 		} else {
 			projects, err := apiClient.FetchProjects()
 			if err != nil {
-				log.Fatalf("Error fetching projects 2: %v", err)
+				return err
+			}
+			if guess := speculativeProject(projects); guess != "" {
+				prefetch = prefetchProject(apiClient, guess, needBranches, needPipelines)
+			}
+			project, err = searchProject(projects)
+			if err != nil {
+				return err
 			}
-			project = searchProject(projects)
+		}
+
+		if prefetch == nil || prefetch.project != project {
+			prefetch = prefetchProject(apiClient, project, needBranches, needPipelines)
 		}
 
 		if branchFlag != "" || currentFlag {
 			if branch == "" {
 				branch = branchFlag
 			}
-			fmt.Printf("Looking up branch: %s\n", branch)
+			slog.Info("Looking up branch", "branch", branch)
 			branchFound, err := apiClient.FetchBranchByName(project, branch)
-			if err != nil {
-				log.Fatalf("Error: %v", err.Errors)
+			if isNotFound(err) && currentFlag && !dryRunFlag {
+				branchFound, err = pushMissingBranch(apiClient, config, project, branch)
+				if err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
 			}
-			log.Println("Branch found.", branchFound.Name)
+			slog.Debug("Branch found", "branch", branchFound.Name)
 			branch = branchFound.Name
 		} else if branch == "" {
-			branches, err := apiClient.FetchBranches(project)
+			branches, err := prefetch.Branches()
+			if err != nil {
+				return err
+			}
+			branch, err = searchBranch(branches)
 			if err != nil {
-				log.Fatalf("Error fetching branches: %v", err)
+				return err
 			}
-			branch = searchBranch(branches)
 		}
 
 		if pipelineFlag != "" {
-			fmt.Printf("Using pipeline passed as flag: %s\n", pipelineFlag)
-			pipelineFound, err := apiClient.FetchPipelineByID(project, pipelineFlag)
+			slog.Info("Looking up pipeline", "pipeline", pipelineFlag)
+			pipelineFound, err := findPipeline(apiClient, project, pipelineFlag)
 			if err != nil {
-				log.Fatalf("Error: %v", err)
+				return err
 			}
-			log.Println("Pipeline found.", pipelineFound.ID, pipelineFound.Name)
+			slog.Debug("Pipeline found", "pipeline", pipelineFound.Name, "pipeline_id", pipelineFound.ID)
 			pipeline = *pipelineFound
-			return
 		} else {
-			pipelines, err := apiClient.FetchPipelines(project)
+			pipelines, err := prefetch.Pipelines()
 			if err != nil {
-				log.Fatalf("Error fetching pipelines: %v", err)
+				return err
+			}
+			pipeline, err = searchPipeline(pipelines, branch)
+			if err != nil {
+				return err
 			}
-			pipeline = searchPipeline(pipelines, branch)
 		}
 
-		if pipeline.Name == config.Protected.Pipeline {
-			red := color.New(color.FgRed).SprintFunc()
-			log.Fatalf(red("Error: Unable to deploy protected pipeline: %s"), config.Protected.Pipeline)
-			return
-		} else if branch == config.Protected.Branch {
-			red := color.New(color.FgRed).SprintFunc()
-			log.Fatalf(red("Error: Unable to deploy protected branch: %s"), config.Protected.Branch)
-			return
+		var gitStatus *util.GitStatus
+		if currentFlag {
+			status, err := util.GetGitStatus()
+			if err != nil {
+				return err
+			}
+			gitStatus = &status
 		}
 
-		// Final output with color
-		cyan := color.New(color.FgCyan).SprintFunc()
-		bold := color.New(color.Bold).SprintFunc()
-
-		log.Printf("You selected project: %s\n", cyan(bold(project)))
-		log.Printf("You selected branch: %s\n", cyan(bold(branch)))
-		log.Printf("You selected pipeline: %s(%s)", cyan(bold(pipeline.Name)), cyan(bold(pipeline.ID)))
-
-		if !confirmDeployment() {
-			log.Println("Deployment canceled.")
-			return
+		revision, err := resolveRevision(apiClient, project, branch, gitStatus)
+		if err != nil {
+			return err
 		}
 
-		// Proceed with deployment logic (e.g., calling the Buddy API)
-		log.Println("Proceeding with deployment...")
-		// Call the function to deploy the pipeline
-
-		execution, err := apiClient.RunPipeline(project, pipeline.ID, branch)
+		if dryRunFlag {
+			return printDryRun(apiClient, config, []*deployTarget{{Name: project, Project: project, Branch: branch, Pipeline: pipeline, Revision: revision, Git: gitStatus}})
+		}
 
-		if err != nil {
-			log.Fatalf("Error: %v", err)
+		if !pipelineMatchesBranch(pipeline, branch) {
+			slog.Warn("branch does not match the refs of the pipeline", "branch", branch, "pipeline", pipeline.Name, "refs", formatRefs(pipeline.Refs))
 		}
-		log.Printf("Pipeline execution successfully! \nTriggered On: %s\nStatus: %s\n", cyan(execution.TriggeredOn), cyan(execution.Status))
-		log.Printf("Executed By: %s\n", cyan(execution.Creator.Name))
-		log.Printf("Checkout the execution at: %s", cyan(execution.HTMLURL))
 
-		for {
-			ok, err := checkStatus()
+		entry := newHistoryEntry("deploy", config, project, branch, pipeline, revision.Revision)
+		if err := checkProtection(config, pipeline, branch); err != nil {
+			return refuseDeployment(entry, err)
+		}
+		if gitStatus != nil {
+			entry.ProtectionOverridden, err = checkGitSafety(config, pipeline, *gitStatus)
 			if err != nil {
-				log.Printf("Unable to check status: %v\n Checkout the pipeline: %s", err, execution.Pipeline.URL)
-			}
-
-			if ok {
-				status, err := apiClient.CheckPipelineStatus(project, pipeline.ID, execution.ID)
-				if err != nil {
-					log.Printf("Error: %v", err)
-					break
-				}
-				success := color.New(color.FgGreen).SprintFunc()
-				inProgress := color.New(color.FgYellow).SprintFunc()
-				failed := color.New(color.FgRed).SprintFunc()
-
-				if *status == "SUCCESSFUL" {
-					log.Printf("Current status: %s", success(*status))
-					log.Println("Goodbye!")
-					break
-				} else if *status == "INPROGRESS" {
-					log.Printf("Current status: %s", inProgress(*status))
-					log.Printf("\nWaiting...")
-					time.Sleep(7 * time.Second) // Adjust the sleep duration as needed
-				} else if *status == "FAILED" {
-					log.Printf("Current status: %s", failed(*status))
-					log.Println("Goodbye!")
-					break
-				}
-			} else {
-				log.Println("Goodbye!")
-				break
+				return refuseDeployment(entry, err)
 			}
 		}
+
+		slog.Info("You selected", "project", project, "branch", branch, "pipeline", pipeline.Name, "pipeline_id", pipeline.ID)
+		slog.Info("Revision to deploy", "revision", shortRevision(revision.Revision), "subject", firstLine(revision.Message))
+
+		showChangelog(apiClient, project, pipeline, revision.Revision)
+
+		return runDeployment(apiClient, config, entry)
 	},
 }
 
@@ -189,11 +216,177 @@ func init() {
 	deployCmd.Flags().StringVarP(&branchFlag, "branch", "b", "", "Branch to deploy")
 	deployCmd.Flags().StringVarP(&pipelineFlag, "pipeline", "p", "", "Pipeline to deploy (production or staging)")
 	deployCmd.Flags().BoolVarP(&currentFlag, "current", "c", false, "Use the current Git branch for deployment")
+	deployCmd.Flags().StringVarP(&groupFlag, "group", "g", "", "Deploy every project in the named project group")
+	deployCmd.Flags().IntVar(&parallelFlag, "parallel", 3, "Maximum number of projects deployed at the same time")
+	deployCmd.Flags().BoolVar(&allowDirtyFlag, "allow-dirty", false, "Deploy protected or production pipelines with --current despite uncommitted changes")
+	deployCmd.Flags().BoolVar(&allowUnpushedFlag, "allow-unpushed", false, "Deploy protected or production pipelines with --current despite unpushed commits")
+	deployCmd.Flags().BoolVar(&pushFlag, "push", false, "Push the current branch without asking when it is missing on the remote")
+	deployCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Show what would be deployed without running the pipeline")
+	deployCmd.Flags().BoolVar(&waitFlag, "wait", false, "Wait for the execution to finish without asking to check its status")
+	deployCmd.Flags().BoolVar(&cancelOnInterruptFlag, "cancel-on-interrupt", false, "Cancel the execution instead of detaching when interrupted while waiting")
+	deployCmd.ValidArgsFunction = completeDeployTargets
+	deployCmd.RegisterFlagCompletionFunc("branch", completeBranches)
+	deployCmd.RegisterFlagCompletionFunc("pipeline", completePipelines)
+	deployCmd.RegisterFlagCompletionFunc("group", completeGroups)
 	rootCmd.AddCommand(deployCmd)
 }
 
+// runDeployment asks for confirmation, triggers the entry's pipeline pinned to its revision,
+// follows the execution, records the attempt in the history and notifies the webhooks once it finished.
+// It fails with errPipelineFailed when the execution finished without succeeding.
+func runDeployment(apiClient buddy.BuddyAPI, config Config, entry HistoryEntry) error {
+	confirmed, err := confirmDeployment()
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		entry.Status = "CANCELED"
+		recordHistory(&entry)
+		slog.Info("Deployment canceled")
+		return printData([]HistoryEntry{entry}, nil)
+	}
+
+	hooks := loadHooks(config)
+	if err := runHooks(hooks.BeforeTrigger, newHookEvent("before_trigger", entry, "")); err != nil {
+		entry.Status = "VETOED"
+		entry.Error = err.Error()
+		recordHistory(&entry)
+		if err := printData([]HistoryEntry{entry}, nil); err != nil {
+			return err
+		}
+		return classify(errProtection, fmt.Errorf("deployment vetoed, %w", err))
+	}
+
+	slog.Info("Proceeding with deployment")
+
+	execution, err := apiClient.RunPipeline(entry.Project, entry.PipelineID, entry.Branch, entry.Revision)
+
+	if err != nil {
+		entry.Status = "TRIGGER_FAILED"
+		entry.Error = err.Error()
+		recordHistory(&entry)
+		if err := printData([]HistoryEntry{entry}, nil); err != nil {
+			return err
+		}
+		return err
+	}
+	entry.ExecutionID = execution.ID
+	entry.URL = execution.HTMLURL
+	entry.Creator = execution.Creator.Name
+	entry.Status = execution.Status
+	runHooksOrWarn(hooks.AfterTrigger, newHookEvent("after_trigger", entry, entry.URL))
+
+	entry.Status = followExecution(apiClient, entry.Project, entry.PipelineID, execution)
+	recordHistory(&entry)
+
+	if isFinalStatus(entry.Status) {
+		notifyWebhooks(apiClient, config, entry.Project, entry.PipelineID, entry.ExecutionID)
+		runHooksOrWarn(hooks.AfterCompletion, newHookEvent("after_completion", entry, entry.URL))
+	}
+
+	if err := printData([]HistoryEntry{entry}, nil); err != nil {
+		return err
+	}
+	if isFinalStatus(entry.Status) && entry.Status != "SUCCESSFUL" {
+		return classify(errPipelineFailed, fmt.Errorf("execution %d of pipeline %s finished with status %s", entry.ExecutionID, entry.Pipeline, entry.Status))
+	}
+	return nil
+}
+
+// resolveRevision returns the commit to deploy. With --current, status describes the local branch and the local HEAD
+// is deployed when it is on the upstream. Buddy can't deploy unpushed commits, the tip of the branch in Buddy is deployed otherwise.
+// The execution is pinned to this commit so a push landing after confirmation is not deployed.
+func resolveRevision(apiClient buddy.BuddyAPI, project, branch string, status *util.GitStatus) (buddy.Revision, error) {
+	if status != nil && status.Pushed() {
+		sha, subject, err := util.GetHeadCommit()
+		if err != nil {
+			return buddy.Revision{}, err
+		}
+		return buddy.Revision{Revision: sha, Message: subject}, nil
+	}
+
+	commit, err := apiClient.FetchLatestCommit(project, branch)
+	if err != nil {
+		return buddy.Revision{}, fmt.Errorf("error fetching latest commit: %w", err)
+	}
+	return *commit, nil
+}
+
+// followExecution prints the triggered execution and checks its status for as long as the user wants to,
+// or until it finished with --wait.
+// Interrupting the wait detaches from or cancels the execution, see interruptAction.
+// It returns the last known status of the execution.
+func followExecution(apiClient buddy.BuddyAPI, project string, pipelineID int, execution *buddy.PipelineExecutionResponse) string {
+	lastStatus := execution.Status
+
+	slog.Info("Pipeline executed successfully", "triggered_on", execution.TriggeredOn, "status", execution.Status, "executed_by", execution.Creator.Name)
+	slog.Info("Checkout the execution", "url", execution.HTMLURL)
+
+	for {
+		ok := waitFlag
+		if !waitFlag {
+			var err error
+			ok, err = checkStatus()
+			if err != nil {
+				slog.Warn("unable to check status", "error", err, "pipeline_url", execution.Pipeline.URL)
+			}
+		}
+
+		if ok {
+			status, err := apiClient.CheckPipelineStatus(project, pipelineID, execution.ID)
+			if err != nil {
+				slog.Error("unable to check status", "error", err)
+				break
+			}
+			lastStatus = *status
+			slog.Info("Current status", "status", *status)
+
+			if isFinalStatus(*status) {
+				slog.Info("Goodbye!")
+				break
+			}
+			slog.Info("Waiting...")
+			if !waitForExecution(apiClient, project, pipelineID, execution) {
+				break
+			}
+		} else {
+			slog.Info("Goodbye!")
+			break
+		}
+	}
+
+	return lastStatus
+}
+
+// waitForExecution waits for the next status check. When interrupted it detaches, cancels the execution
+// or keeps waiting, and it returns false once the user detached. Interrupts are only trapped while waiting,
+// during prompts and requests they stop gobuddy as usual.
+func waitForExecution(apiClient buddy.BuddyAPI, project string, pipelineID int, execution *buddy.PipelineExecutionResponse) bool {
+	interrupts := notifyInterrupts()
+	defer signal.Stop(interrupts)
+
+	select {
+	case <-time.After(statusPollInterval):
+		return true
+	case sig := <-interrupts:
+		switch interruptAction(sig) {
+		case interruptDetach:
+			slog.Info("Detached, the execution keeps running", "url", execution.HTMLURL)
+			return false
+		case interruptCancel:
+			// A second interrupt stops gobuddy right away
+			signal.Stop(interrupts)
+			slog.Info("Canceling the execution...")
+			if _, err := apiClient.CancelExecution(project, pipelineID, execution.ID); err != nil {
+				slog.Error("unable to cancel the execution", "error", err)
+			}
+		}
+		return true
+	}
+}
+
 // Function to search and select project interactively
-func searchProject(projectsArray []buddy.Project) string {
+func searchProject(projectsArray []buddy.Project) (string, error) {
 	var projectNames []string
 
 	// Loop over the []Project slice and extract the Name
@@ -201,33 +394,16 @@ func searchProject(projectsArray []buddy.Project) string {
 		projectNames = append(projectNames, project.Name)
 	}
 
-	prompt := promptui.Select{
-		Label: "Select a Project",
-		Items: projectNames,
-		Searcher: func(input string, index int) bool {
-			project := projectNames[index]
-			return containsIgnoreCase(project, input)
-		},
-		StartInSearchMode: true, // Start the prompt in search mode
-		Templates: &promptui.SelectTemplates{
-			Label:    "{{ . | bold }}",
-			Active:   "▸ {{ . | cyan | bold }}",
-			Inactive: "  {{ . | cyan }}",
-			Selected: "✔  {{ . | cyan | bold }}",
-		},
-	}
-
-	i, _, err := prompt.Run()
-
+	i, err := prompter.Select(SelectPrompt{Label: "Select a Project", Items: projectNames, Color: "cyan", Search: true})
 	if err != nil {
-		log.Fatalf("Prompt failed %v\n", err)
+		return "", err
 	}
 
-	return projectNames[i]
+	return projectNames[i], nil
 }
 
 // Function to search and select branch interactively
-func searchBranch(branchesArray []buddy.Branch) string {
+func searchBranch(branchesArray []buddy.Branch) (string, error) {
 	var branchNames []string
 
 	// Loop over the []Branch slice and extract the Name
@@ -235,105 +411,128 @@ func searchBranch(branchesArray []buddy.Branch) string {
 		branchNames = append(branchNames, branch.Name)
 	}
 
-	prompt := promptui.Select{
-		Label: "Select a Branch",
-		Items: branchNames,
-		Searcher: func(input string, index int) bool {
-			branch := branchNames[index]
-			return containsIgnoreCase(branch, input)
-		},
-		StartInSearchMode: true, // Start the prompt in search mode
-		Templates: &promptui.SelectTemplates{
-			Label:    "{{ . | bold }}",
-			Active:   "▸ {{ . | green | bold }}",
-			Inactive: "  {{ . | green }}",
-			Selected: "✔  {{ . | green | bold }}",
-		},
-	}
-
-	i, _, err := prompt.Run()
-
+	i, err := prompter.Select(SelectPrompt{Label: "Select a Branch", Items: branchNames, Color: "green", Search: true})
 	if err != nil {
-		log.Fatalf("Prompt failed %v\n", err)
+		return "", err
 	}
 
-	return branchNames[i]
+	return branchNames[i], nil
 }
 
 // Function to select pipeline interactively (production or staging)
-func searchPipeline(pipelinesArray []buddy.Pipeline, _ string) buddy.Pipeline {
-	var availablePipelines []string
-	for _, pipelines := range pipelinesArray {
-		availablePipelines = append(availablePipelines, pipelines.Name)
+// Pipelines whose refs match the selected branch are listed first.
+func searchPipeline(pipelinesArray []buddy.Pipeline, branch string) (buddy.Pipeline, error) {
+	sorted := make([]buddy.Pipeline, len(pipelinesArray))
+	copy(sorted, pipelinesArray)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return pipelineMatchesBranch(sorted[i], branch) && !pipelineMatchesBranch(sorted[j], branch)
+	})
+
+	prompt := SelectPrompt{Color: "magenta"}
+	for _, pipeline := range sorted {
+		note := ""
+		if !pipelineMatchesBranch(pipeline, branch) {
+			note = "(refs do not match)"
+		}
+		prompt.Items = append(prompt.Items, pipeline.Name)
+		prompt.Notes = append(prompt.Notes, note)
+		prompt.Details = append(prompt.Details, fmt.Sprintf("Priority: %s\nRefs:     %s", pipeline.Priority, formatRefs(pipeline.Refs)))
 	}
 
-	prompt := promptui.Select{
-		Label: "Select Pipeline",
-		Items: availablePipelines,
-		Templates: &promptui.SelectTemplates{
-			Label:    "{{ . | bold }}",
-			Active:   "▸ {{ . | magenta | bold }}",
-			Inactive: "  {{ . | magenta }}",
-			Selected: "✔  {{ . | magenta | bold }}",
-		},
+	prompt.Label = "Select Pipeline"
+	if branch != "" {
+		prompt.Label = fmt.Sprintf("Select Pipeline for branch %s", branch)
 	}
 
-	i, _, err := prompt.Run()
-
+	i, err := prompter.Select(prompt)
 	if err != nil {
-		log.Fatalf("Prompt failed %v\n", err)
+		return buddy.Pipeline{}, err
 	}
 
-	selectedPipeline := filterPipelineByName(pipelinesArray, availablePipelines[i])
-	return *selectedPipeline
+	return sorted[i], nil
 }
 
-func filterPipelineByName(pipelines []buddy.Pipeline, name string) *buddy.Pipeline {
-	for _, pipeline := range pipelines {
-		if pipeline.Name == name {
-			return &pipeline // Return the pipeline if the name matches
+// pipelineMatchesBranch reports whether the branch matches one of the pipeline's refs patterns.
+// Pipelines without refs can be run on any branch, and every pipeline matches when no branch is known yet.
+func pipelineMatchesBranch(pipeline buddy.Pipeline, branch string) bool {
+	if len(pipeline.Refs) == 0 || branch == "" {
+		return true
+	}
+
+	for _, ref := range pipeline.Refs {
+		pattern := strings.TrimPrefix(ref, "refs/heads/")
+		if pattern == "*" || pattern == branch {
+			return true
+		}
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
 		}
 	}
-	return nil // Return nil if no match is found
+	return false
 }
 
-func confirmDeployment() bool {
-	prompt := promptui.Prompt{
-		Label: "Are you sure you want to deploy (yes/no)",
-		Validate: func(input string) error {
-			if strings.ToLower(input) != "yes" && strings.ToLower(input) != "no" {
-				return fmt.Errorf("please type 'yes' or 'no'")
-			}
-			return nil
-		},
+// formatRefs joins a pipeline's refs for display
+func formatRefs(refs []string) string {
+	if len(refs) == 0 {
+		return "any"
 	}
+	return strings.Join(refs, ", ")
+}
 
-	result, err := prompt.Run()
-	if err != nil {
-		log.Fatalf("Prompt failed %v\n", err)
+// checkProtection fails when the pipeline or branch is protected in the configuration
+func checkProtection(config Config, pipeline buddy.Pipeline, branch string) error {
+	violations := protectionViolations(config, pipeline, branch)
+	if len(violations) > 0 {
+		return classify(errProtection, errors.New(strings.Join(violations, ", ")))
 	}
+	return nil
+}
 
-	return strings.ToLower(result) == "yes"
+// protectionViolations returns every protection rule the deployment breaks
+func protectionViolations(config Config, pipeline buddy.Pipeline, branch string) []string {
+	var violations []string
+	if pipeline.Name == config.Protected.Pipeline {
+		violations = append(violations, fmt.Sprintf("Unable to deploy protected pipeline: %s", config.Protected.Pipeline))
+	}
+	if branch == config.Protected.Branch {
+		violations = append(violations, fmt.Sprintf("Unable to deploy protected branch: %s", config.Protected.Branch))
+	}
+	return violations
 }
 
-func checkStatus() (bool, error) {
-	prompt := promptui.Prompt{
-		Label: "Want to check the status (yes/no)",
-		Validate: func(input string) error {
-			if strings.ToLower(input) != "yes" && strings.ToLower(input) != "no" {
-				return fmt.Errorf("please type 'yes' or 'no'")
-			}
-			return nil
-		},
+// findPipeline looks up a pipeline of the project by ID when nameOrID is numeric, or by name otherwise
+func findPipeline(apiClient buddy.BuddyAPI, project, nameOrID string) (*buddy.Pipeline, error) {
+	if _, err := strconv.Atoi(nameOrID); err == nil {
+		return apiClient.FetchPipelineByID(project, nameOrID)
 	}
 
-	result, err := prompt.Run()
+	pipelines, err := apiClient.FetchPipelines(project)
 	if err != nil {
-		fmt.Printf("Prompt failed: %v\n", err)
-		return false, err
+		return nil, err
+	}
+
+	pipeline := filterPipelineByName(pipelines, nameOrID)
+	if pipeline == nil {
+		return nil, classify(errNotFound, fmt.Errorf("pipeline %s not found in project %s", nameOrID, project))
+	}
+	return pipeline, nil
+}
+
+func filterPipelineByName(pipelines []buddy.Pipeline, name string) *buddy.Pipeline {
+	for _, pipeline := range pipelines {
+		if pipeline.Name == name {
+			return &pipeline // Return the pipeline if the name matches
+		}
 	}
+	return nil // Return nil if no match is found
+}
+
+func confirmDeployment() (bool, error) {
+	return prompter.Confirm("Are you sure you want to deploy")
+}
 
-	return strings.ToLower(result) == "yes", nil
+func checkStatus() (bool, error) {
+	return prompter.Confirm("Want to check the status")
 }
 
 // Helper function to do case-insensitive search
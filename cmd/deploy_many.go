@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/JacobAndrewSmith92/gobuddy/internal/util"
+	"github.com/fatih/color"
+)
+
+// statusPollInterval is how long to wait between pipeline status checks, tests shorten it
+var statusPollInterval = 7 * time.Second
+
+// deployTarget is a single pipeline run within a multi project deploy or plan
+type deployTarget struct {
+	Name     string
+	Project  string
+	Branch   string
+	Pipeline buddy.Pipeline
+	Revision buddy.Revision
+	Status   string
+	URL      string
+	Err      error
+	// ExecutionID and Creator are set once the pipeline is triggered
+	ExecutionID int
+	Creator     string
+	// Git is the state of the local branch when deploying with --current, nil otherwise
+	Git *util.GitStatus
+	// Detached is set when gobuddy stopped watching an execution that keeps running in Buddy
+	Detached bool
+	// ProtectionOverridden is set when an --allow-* flag was needed to deploy the target
+	ProtectionOverridden bool
+}
+
+// deployMany triggers the same pipeline on several projects with bounded parallelism
+// and fails with errPipelineFailed if any deployment fails.
+func deployMany(apiClient buddy.BuddyAPI, config Config, projects []string) error {
+	branch := branchFlag
+	var gitStatus *util.GitStatus
+	if currentFlag {
+		currentBranch, err := util.GetBranch()
+		if err != nil {
+			return err
+		}
+		branch = currentBranch
+
+		status, err := util.GetGitStatus()
+		if err != nil {
+			return err
+		}
+		gitStatus = &status
+	}
+
+	if branch == "" {
+		branches, err := apiClient.FetchBranches(projects[0])
+		if err != nil {
+			return err
+		}
+		branch, err = searchBranch(branches)
+		if err != nil {
+			return err
+		}
+	}
+
+	pipelineName, err := resolvePipelineName(apiClient, projects[0], branch)
+	if err != nil {
+		return err
+	}
+
+	var targets []*deployTarget
+	for _, project := range projects {
+		slog.Info("Looking up project", "project", project)
+		if _, err := apiClient.FetchProjectByName(project); err != nil {
+			return err
+		}
+		if _, err := apiClient.FetchBranchByName(project, branch); err != nil {
+			return err
+		}
+
+		pipeline, err := findPipeline(apiClient, project, pipelineName)
+		if err != nil {
+			return err
+		}
+
+		commit, err := apiClient.FetchLatestCommit(project, branch)
+		if err != nil {
+			return fmt.Errorf("error fetching latest commit of %s: %w", project, err)
+		}
+
+		target := &deployTarget{
+			Name:     project,
+			Project:  project,
+			Branch:   branch,
+			Pipeline: *pipeline,
+			Revision: *commit,
+			Status:   "PENDING",
+			Git:      gitStatus,
+		}
+
+		if !dryRunFlag {
+			entry := newHistoryEntry("deploy", config, project, branch, *pipeline, commit.Revision)
+			if err := checkProtection(config, *pipeline, branch); err != nil {
+				return refuseDeployment(entry, err)
+			}
+			if gitStatus != nil {
+				target.ProtectionOverridden, err = checkGitSafety(config, *pipeline, *gitStatus)
+				if err != nil {
+					return refuseDeployment(entry, err)
+				}
+			}
+			if !pipelineMatchesBranch(*pipeline, branch) {
+				slog.Warn("branch does not match the refs of the pipeline", "project", project, "branch", branch, "pipeline", pipeline.Name, "refs", formatRefs(pipeline.Refs))
+			}
+		}
+
+		targets = append(targets, target)
+	}
+
+	if dryRunFlag {
+		return printDryRun(apiClient, config, targets)
+	}
+
+	slog.Info("You selected", "projects", strings.Join(projects, ","), "branch", branch, "pipeline", pipelineName)
+	for _, target := range targets {
+		slog.Info("Revision to deploy", "project", target.Project, "revision", shortRevision(target.Revision.Revision), "subject", firstLine(target.Revision.Message))
+	}
+
+	return runTargetsConfirmed(apiClient, config, "deploy", targets, func() {
+		runTargets(apiClient, config, "deploy", targets, parallelFlag)
+	})
+}
+
+// uniqueProjects drops repeated projects, keeping the first occurrence, so a pipeline never runs twice on a project
+func uniqueProjects(projects []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, project := range projects {
+		if !seen[project] {
+			seen[project] = true
+			unique = append(unique, project)
+		}
+	}
+	return unique
+}
+
+// runTargetsConfirmed asks for confirmation and runs the targets of a multi project deploy or plan with run.
+// Every target is recorded in the history, and it fails with errPipelineFailed if any deployment failed.
+func runTargetsConfirmed(apiClient buddy.BuddyAPI, config Config, command string, targets []*deployTarget, run func()) error {
+	confirmed, err := confirmDeployment()
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		for _, target := range targets {
+			target.Status = "CANCELED"
+		}
+		entries := recordTargets(command, config, targets)
+		slog.Info("Deployment canceled")
+		return printData(entries, nil)
+	}
+
+	run()
+	entries := recordTargets(command, config, targets)
+	notifyTargets(apiClient, config, targets)
+
+	failed := printDeploySummary(targets)
+	if err := printData(entries, nil); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return classify(errPipelineFailed, fmt.Errorf("%d of %d deployments failed", failed, len(targets)))
+	}
+	return nil
+}
+
+// resolvePipelineName returns the pipeline name to run on every project,
+// taken from the pipeline flag (name or ID) or selected from the first project's pipelines.
+func resolvePipelineName(apiClient buddy.BuddyAPI, project, branch string) (string, error) {
+	if pipelineFlag == "" {
+		pipelines, err := apiClient.FetchPipelines(project)
+		if err != nil {
+			return "", err
+		}
+		pipeline, err := searchPipeline(pipelines, branch)
+		return pipeline.Name, err
+	}
+
+	if _, err := strconv.Atoi(pipelineFlag); err != nil {
+		return pipelineFlag, nil
+	}
+
+	pipelineFound, err := apiClient.FetchPipelineByID(project, pipelineFlag)
+	if err != nil {
+		return "", err
+	}
+	return pipelineFound.Name, nil
+}
+
+// runTargets runs the pipeline of every target, at most parallel at a time,
+// and redraws a status table until every execution has finished.
+func runTargets(apiClient buddy.BuddyAPI, config Config, command string, targets []*deployTarget, parallel int) {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	hooks := loadHooks(config)
+	ctx, stop := context.WithCancelCause(context.Background())
+	defer stop(nil)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallel)
+
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target *deployTarget) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			executeTarget(ctx, apiClient, config, command, hooks, target, &mu)
+		}(target)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	watchTargets(targets, &mu, done, stop)
+}
+
+// executeTarget runs the before trigger hooks, triggers the target's pipeline and polls its status
+// until the execution finishes. Once ctx is stopped no pipeline is triggered anymore, and a running
+// execution is left running or canceled depending on the cause. The target is only modified while holding mu.
+func executeTarget(ctx context.Context, apiClient buddy.BuddyAPI, config Config, command string, hooks Hooks, target *deployTarget, mu *sync.Mutex) {
+	update := func(status string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		target.Status = status
+		target.Err = err
+	}
+
+	if ctx.Err() != nil {
+		update("SKIPPED", nil)
+		return
+	}
+
+	entry := newHistoryEntry(command, config, target.Project, target.Branch, target.Pipeline, target.Revision.Revision)
+	if err := runHooks(hooks.BeforeTrigger, newHookEvent("before_trigger", entry, "")); err != nil {
+		update("VETOED", err)
+		return
+	}
+
+	update("TRIGGERING", nil)
+	execution, err := apiClient.RunPipeline(target.Project, target.Pipeline.ID, target.Branch, target.Revision.Revision)
+	if err != nil {
+		update("FAILED", err)
+		return
+	}
+	mu.Lock()
+	target.URL = execution.HTMLURL
+	target.ExecutionID = execution.ID
+	target.Creator = execution.Creator.Name
+	mu.Unlock()
+	update(execution.Status, nil)
+
+	entry.ExecutionID = execution.ID
+	entry.Status = execution.Status
+	runHooksOrWarn(hooks.AfterTrigger, newHookEvent("after_trigger", entry, execution.HTMLURL))
+
+	stopped := ctx.Done()
+	for !isFinalStatus(execution.Status) {
+		select {
+		case <-time.After(statusPollInterval):
+		case <-stopped:
+			stopped = nil
+			if context.Cause(ctx) != errCancelOnInterrupt {
+				mu.Lock()
+				target.Detached = true
+				mu.Unlock()
+				return
+			}
+			if _, err := apiClient.CancelExecution(target.Project, target.Pipeline.ID, execution.ID); err != nil {
+				update(execution.Status, fmt.Errorf("unable to cancel the execution: %v", err))
+			}
+		}
+
+		status, err := apiClient.CheckPipelineStatus(target.Project, target.Pipeline.ID, execution.ID)
+		if err != nil {
+			update("UNKNOWN", err)
+			return
+		}
+		execution.Status = *status
+		update(*status, nil)
+	}
+
+	entry.Status = execution.Status
+	runHooksOrWarn(hooks.AfterCompletion, newHookEvent("after_completion", entry, execution.HTMLURL))
+}
+
+// watchTargets redraws the status table every second until done is closed, or prints a line per status change
+// in plain mode. When interrupted the executions are detached from or canceled through stop, see interruptAction.
+func watchTargets(targets []*deployTarget, mu *sync.Mutex, done <-chan struct{}, stop context.CancelCauseFunc) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	interrupts := notifyInterrupts()
+	defer signal.Stop(interrupts)
+
+	lines := 0
+	printed := make(map[*deployTarget]string)
+	draw := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if plainStatus() {
+			printStatusChanges(targets, printed)
+		} else {
+			lines = drawStatusTable(targets, lines)
+		}
+	}
+
+	for {
+		draw()
+
+		select {
+		case <-done:
+			draw()
+			return
+		case sig := <-interrupts:
+			switch interruptAction(sig) {
+			case interruptDetach:
+				slog.Info("Detached, the executions keep running")
+				stop(errDetached)
+				signal.Stop(interrupts)
+			case interruptCancel:
+				slog.Info("Canceling the executions...")
+				stop(errCancelOnInterrupt)
+				signal.Stop(interrupts)
+			}
+			// The prompt moved the cursor, draw a new table below it
+			lines = 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// drawStatusTable prints the status of every target, overwriting the previously drawn table
+// and returns the number of lines written.
+func drawStatusTable(targets []*deployTarget, previousLines int) int {
+	if previousLines > 0 {
+		fmt.Fprintf(humanOut, "\033[%dA", previousLines)
+	}
+
+	width := len("NAME")
+	for _, target := range targets {
+		width = max(width, len(target.Name))
+	}
+
+	fmt.Fprintf(humanOut, "\033[2K%-*s  %-14s  %s\n", width, "NAME", "STATUS", "URL")
+	for _, target := range targets {
+		fmt.Fprintf(humanOut, "\033[2K%-*s  %-14s  %s\n", width, target.Name, colorStatus(target.Status, 14), target.URL)
+	}
+	return len(targets) + 1
+}
+
+// printStatusChanges prints a line for every target whose status changed since it was last printed
+func printStatusChanges(targets []*deployTarget, printed map[*deployTarget]string) {
+	for _, target := range targets {
+		if printed[target] == target.Status {
+			continue
+		}
+		printed[target] = target.Status
+		if target.URL != "" {
+			fmt.Fprintf(humanOut, "%s: %s, %s\n", target.Name, target.Status, target.URL)
+		} else {
+			fmt.Fprintf(humanOut, "%s: %s\n", target.Name, target.Status)
+		}
+	}
+}
+
+// printDeploySummary prints the outcome of every target and returns the number of failed deployments.
+// Detached executions are still running and don't count as failed.
+func printDeploySummary(targets []*deployTarget) int {
+	failed, detached := 0, 0
+	fmt.Fprintln(humanOut)
+	fmt.Fprintln(humanOut, color.New(color.Bold).Sprint("Deployment summary:"))
+	for _, target := range targets {
+		switch {
+		case target.Detached:
+			detached++
+			fmt.Fprintf(humanOut, "  %s: %s (detached, still running in Buddy)\n", target.Name, colorStatus(target.Status, 0))
+			continue
+		case target.Status != "SUCCESSFUL":
+			failed++
+		}
+		if target.Err != nil {
+			fmt.Fprintf(humanOut, "  %s: %s (%v)\n", target.Name, colorStatus(target.Status, 0), target.Err)
+			continue
+		}
+		fmt.Fprintf(humanOut, "  %s: %s\n", target.Name, colorStatus(target.Status, 0))
+	}
+	if detached > 0 {
+		fmt.Fprintf(humanOut, "%d succeeded, %d failed, %d detached\n", len(targets)-failed-detached, failed, detached)
+	} else {
+		fmt.Fprintf(humanOut, "%d succeeded, %d failed\n", len(targets)-failed, failed)
+	}
+	return failed
+}
+
+// isFinalStatus reports whether an execution status will no longer change
+func isFinalStatus(status string) bool {
+	switch status {
+	case "SUCCESSFUL", "FAILED", "TERMINATED", "SKIPPED", "NOT_EXECUTED":
+		return true
+	}
+	return false
+}
+
+// colorStatus colors an execution status, padding it to width before coloring so tables stay aligned
+func colorStatus(status string, width int) string {
+	padded := fmt.Sprintf("%-*s", width, status)
+	switch status {
+	case "SUCCESSFUL":
+		return color.New(color.FgGreen).Sprint(padded)
+	case "FAILED", "TERMINATED", "UNKNOWN":
+		return color.New(color.FgRed).Sprint(padded)
+	case "INPROGRESS", "ENQUEUED", "INITIAL", "TRIGGERING":
+		return color.New(color.FgYellow).Sprint(padded)
+	}
+	return padded
+}
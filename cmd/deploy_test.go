@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// deployFixtures replay an interactive deploy of the Staging pipeline of api on main
+const deployFixtures = "testdata/deploy"
+
+func TestDeployInteractive(t *testing.T) {
+	useHome(t)
+	writeConfig(t, Config{Token: "token", Workspace: "acme"})
+
+	answers := []string{"api", "main", "staging", "yes", "yes"}
+	if _, err := runGobuddy(t, answers, "deploy", "--replay", deployFixtures); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	entries, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d history entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Project != "api" || entry.Branch != "main" || entry.Pipeline != "Deploy to Staging" || entry.PipelineID != 11 {
+		t.Errorf("deployed %s %s %s (%d), want api main Deploy to Staging (11)", entry.Project, entry.Branch, entry.Pipeline, entry.PipelineID)
+	}
+	if entry.Revision != "3f2a9c1d5e7b" {
+		t.Errorf("revision = %s, want the tip of main 3f2a9c1d5e7b", entry.Revision)
+	}
+	if entry.ExecutionID != 42 || entry.Status != "SUCCESSFUL" {
+		t.Errorf("execution %d finished with %s, want 42 SUCCESSFUL", entry.ExecutionID, entry.Status)
+	}
+	if entry.Timestamp.IsZero() {
+		t.Error("the history entry has no timestamp")
+	}
+}
+
+func TestDeployCanceled(t *testing.T) {
+	useHome(t)
+	writeConfig(t, Config{Token: "token", Workspace: "acme"})
+
+	answers := []string{"no"}
+	if _, err := runGobuddy(t, answers, "deploy", "api", "-b", "main", "-p", "Deploy to Staging", "--replay", deployFixtures); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	entries, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Status != "CANCELED" {
+		t.Fatalf("history = %+v, want a single CANCELED entry", entries)
+	}
+}
+
+func TestDeployProtectedPipeline(t *testing.T) {
+	useHome(t)
+	writeConfig(t, Config{Token: "token", Workspace: "acme", Protected: Protected{Pipeline: "Deploy to Staging"}})
+
+	_, err := runGobuddy(t, nil, "deploy", "api", "-b", "main", "-p", "11", "--replay", deployFixtures)
+	if !errors.Is(err, errProtection) {
+		t.Fatalf("err = %v, want a protection error", err)
+	}
+	if code := exitCode(err); code != exitProtection {
+		t.Errorf("exit code = %d, want %d", code, exitProtection)
+	}
+
+	entries, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Status != "REFUSED" || entries[0].Revision != "3f2a9c1d5e7b" {
+		t.Fatalf("history = %+v, want a single REFUSED entry of revision 3f2a9c1d5e7b", entries)
+	}
+}
+
+func TestDeployWithoutConfig(t *testing.T) {
+	useHome(t)
+
+	_, err := runGobuddy(t, nil, "deploy", "api")
+	if code := exitCode(err); code != exitConfig {
+		t.Fatalf("exit code = %d (%v), want %d", code, err, exitConfig)
+	}
+}
+
+func TestDeploySummaryDetached(t *testing.T) {
+	var out bytes.Buffer
+	humanOut = &out
+	t.Cleanup(func() { humanOut = os.Stdout })
+
+	targets := []*deployTarget{
+		{Name: "api", Status: "SUCCESSFUL"},
+		{Name: "web", Status: "FAILED"},
+		{Name: "worker", Status: "INPROGRESS", Detached: true},
+	}
+	if failed := printDeploySummary(targets); failed != 1 {
+		t.Errorf("printDeploySummary() = %d failed, want 1", failed)
+	}
+	if !strings.Contains(out.String(), "1 succeeded, 1 failed, 1 detached") {
+		t.Errorf("summary doesn't count the detached target:\n%s", out.String())
+	}
+}
+
+func TestPlainStatusWithoutTerminal(t *testing.T) {
+	humanOut = &bytes.Buffer{}
+	t.Cleanup(func() { humanOut = os.Stdout })
+
+	if !plainStatus() {
+		t.Error("plainStatus() = false when not writing to a terminal, want true")
+	}
+}
+
+func TestDeployRejectsCSVBeforeDeploying(t *testing.T) {
+	useHome(t)
+	writeConfig(t, Config{Token: "token", Workspace: "acme"})
+
+	_, err := runGobuddy(t, nil, "deploy", "api", "-b", "main", "-p", "Deploy to Staging", "--replay", deployFixtures, "-o", "csv")
+	if code := exitCode(err); code != exitConfig {
+		t.Fatalf("exit code = %d (%v), want %d", code, err, exitConfig)
+	}
+	if _, err := os.Stat(historyFilePath); !os.IsNotExist(err) {
+		t.Fatalf("the history was written (%v), want nothing deployed", err)
+	}
+
+	if _, err := runGobuddy(t, nil, "history", "-o", "csv"); err != nil {
+		t.Errorf("history -o csv: %v", err)
+	}
+}
+
+func TestUniqueProjects(t *testing.T) {
+	got := uniqueProjects([]string{"api", "web", "api", "worker", "web"})
+	if want := []string{"api", "web", "worker"}; !slices.Equal(got, want) {
+		t.Errorf("uniqueProjects() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/fatih/color"
+)
+
+// DryRun is the result of `deploy --dry-run`, printed as JSON with --output json
+type DryRun struct {
+	Workspace   string             `json:"workspace"`
+	Deployments []DryRunDeployment `json:"deployments"`
+}
+
+// DryRunDeployment describes a single pipeline run that would be triggered
+type DryRunDeployment struct {
+	Project  string                         `json:"project"`
+	Branch   string                         `json:"branch"`
+	Pipeline DryRunPipeline                 `json:"pipeline"`
+	Method   string                         `json:"method"`
+	URL      string                         `json:"url"`
+	Request  buddy.PipelineExecutionRequest `json:"request"`
+	// Subject is the first line of the message of the pinned commit
+	Subject string `json:"subject"`
+	// Violations lists the protection rules and git checks of --current the deployment breaks, the deployment is refused if there are any
+	Violations []string `json:"violations"`
+	Warnings   []string `json:"warnings"`
+	Allowed    bool     `json:"allowed"`
+}
+
+// DryRunPipeline identifies the pipeline of a dry run deployment
+type DryRunPipeline struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Priority string   `json:"priority,omitempty"`
+	Refs     []string `json:"refs,omitempty"`
+}
+
+// printDryRun prints what would be executed for every target without running any pipeline.
+// It fails with a protection violation if a deployment breaks a protection rule or a git check.
+func printDryRun(apiClient buddy.BuddyAPI, config Config, targets []*deployTarget) error {
+	dryRun := DryRun{Workspace: config.Workspace}
+	allowed := true
+
+	for _, target := range targets {
+		deployment := DryRunDeployment{
+			Project: target.Project,
+			Branch:  target.Branch,
+			Pipeline: DryRunPipeline{
+				ID:       target.Pipeline.ID,
+				Name:     target.Pipeline.Name,
+				Priority: target.Pipeline.Priority,
+				Refs:     target.Pipeline.Refs,
+			},
+			Method:     "POST",
+			URL:        apiClient.ExecutionsURL(target.Project, target.Pipeline.ID),
+			Request:    buddy.NewPipelineExecutionRequest(target.Branch, target.Revision.Revision),
+			Subject:    firstLine(target.Revision.Message),
+			Violations: protectionViolations(config, target.Pipeline, target.Branch),
+			Warnings:   []string{},
+		}
+		if target.Git != nil {
+			violations, _ := gitSafetyViolations(config, target.Pipeline, *target.Git)
+			deployment.Violations = append(deployment.Violations, violations...)
+		}
+		if deployment.Violations == nil {
+			deployment.Violations = []string{}
+		}
+		if !pipelineMatchesBranch(target.Pipeline, target.Branch) {
+			deployment.Warnings = append(deployment.Warnings, fmt.Sprintf("branch %s does not match the refs of pipeline %s (%s)", target.Branch, target.Pipeline.Name, formatRefs(target.Pipeline.Refs)))
+		}
+		deployment.Allowed = len(deployment.Violations) == 0
+		allowed = allowed && deployment.Allowed
+
+		dryRun.Deployments = append(dryRun.Deployments, deployment)
+	}
+
+	if err := printData(dryRun, func() { printDryRunText(dryRun) }); err != nil {
+		return err
+	}
+
+	if !allowed {
+		return classify(errProtection, errors.New("the dry run found protection violations"))
+	}
+	return nil
+}
+
+// printDryRunText prints a dry run for humans
+func printDryRunText(dryRun DryRun) {
+	cyan := color.New(color.FgCyan).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	fmt.Fprintln(humanOut, bold("Dry run, no pipeline will be executed:"))
+	for _, deployment := range dryRun.Deployments {
+		fmt.Fprintln(humanOut)
+		fmt.Fprintf(humanOut, "Project: %s\n", cyan(deployment.Project))
+		fmt.Fprintf(humanOut, "Branch: %s\n", cyan(deployment.Branch))
+		fmt.Fprintf(humanOut, "Pipeline: %s(%s)\n", cyan(deployment.Pipeline.Name), cyan(deployment.Pipeline.ID))
+		fmt.Fprintf(humanOut, "Revision: %s %s\n", cyan(deployment.Request.ToRevision.Revision), deployment.Subject)
+		fmt.Fprintln(humanOut, "Variables: none, the pipeline's own variables are used")
+		fmt.Fprintf(humanOut, "Request: %s %s\n", deployment.Method, deployment.URL)
+
+		for _, warning := range deployment.Warnings {
+			fmt.Fprintln(humanOut, yellow("Warning: "+warning))
+		}
+		for _, violation := range deployment.Violations {
+			fmt.Fprintln(humanOut, red("Error: "+violation))
+		}
+		if deployment.Allowed {
+			fmt.Fprintln(humanOut, green("Deployment allowed"))
+		} else {
+			fmt.Fprintln(humanOut, red("Deployment refused"))
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+)
+
+// Exit codes of gobuddy, one per error class. They are part of the documented interface, never renumber them.
+const (
+	exitError          = 1
+	exitConfig         = 2
+	exitAuth           = 3
+	exitNotFound       = 4
+	exitProtection     = 5
+	exitPipelineFailed = 6
+)
+
+// Error classes, errors are marked with classify and mapped to an exit code by exitCode
+var (
+	errConfig         = errors.New("configuration error")
+	errAuth           = errors.New("authentication failed")
+	errNotFound       = errors.New("not found")
+	errProtection     = errors.New("protection violation")
+	errPipelineFailed = errors.New("pipeline failed")
+)
+
+// classifiedError marks an error with its class without changing its message
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
+
+// classify marks err as belonging to an error class
+func classify(class, err error) error {
+	return &classifiedError{class: class, err: err}
+}
+
+// exitCode returns the exit code of the class of err. Buddy API responses are classified by their status
+// when the command didn't classify the error itself.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errConfig):
+		return exitConfig
+	case errors.Is(err, errAuth):
+		return exitAuth
+	case errors.Is(err, errNotFound):
+		return exitNotFound
+	case errors.Is(err, errProtection):
+		return exitProtection
+	case errors.Is(err, errPipelineFailed):
+		return exitPipelineFailed
+	}
+
+	var pluginErr *pluginExitError
+	if errors.As(err, &pluginErr) {
+		return pluginErr.code
+	}
+
+	var statusErr *buddy.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return exitAuth
+		case http.StatusNotFound:
+			return exitNotFound
+		}
+	}
+	return exitError
+}
+
+// isNotFound reports whether the Buddy API answered 404 Not Found
+func isNotFound(err error) bool {
+	var statusErr *buddy.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
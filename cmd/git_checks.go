@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/JacobAndrewSmith92/gobuddy/internal/util"
+)
+
+// branchWaitTimeout is how long to wait for Buddy to see a pushed branch
+const branchWaitTimeout = 2 * time.Minute
+
+// branchPollInterval is how long to wait between branch lookups after pushing
+const branchPollInterval = 3 * time.Second
+
+var allowDirtyFlag bool
+var allowUnpushedFlag bool
+
+// checkGitSafety warns about uncommitted, unpushed or missing commits in the local branch before deploying it.
+// The revision deployed is picked by resolveRevision from the same status.
+// Protected and production pipelines are refused unless the matching --allow-* flag is passed,
+// the returned bool reports whether such a flag was needed to continue.
+func checkGitSafety(config Config, pipeline buddy.Pipeline, status util.GitStatus) (bool, error) {
+	if len(status.Uncommitted) > 0 {
+		slog.Warn("uncommitted changes will not be deployed", "changes", len(status.Uncommitted))
+	}
+	if status.Upstream == "" {
+		slog.Warn("the branch does not track a remote branch, the tip of the branch in Buddy is deployed instead of your local commits")
+	} else if status.Ahead > 0 {
+		slog.Warn("local commits not pushed will not be deployed, the tip of the branch in Buddy is deployed instead", "commits", status.Ahead, "upstream", status.Upstream)
+	}
+	if status.Behind > 0 && status.Pushed() {
+		slog.Warn("the branch is behind its upstream, commits you don't have locally will not be deployed", "commits", status.Behind, "upstream", status.Upstream)
+	} else if status.Behind > 0 {
+		slog.Warn("the branch is behind its upstream, the deployment includes commits you don't have locally", "commits", status.Behind, "upstream", status.Upstream)
+	}
+
+	violations, overridden := gitSafetyViolations(config, pipeline, status)
+	if len(violations) > 0 {
+		return false, classify(errProtection, errors.New(strings.Join(violations, ", ")))
+	}
+	return overridden, nil
+}
+
+// gitSafetyViolations lists why a protected or production pipeline can't be deployed from the local branch.
+// Rules overridden with an --allow-* flag are left out, the returned bool reports whether any was.
+func gitSafetyViolations(config Config, pipeline buddy.Pipeline, status util.GitStatus) ([]string, bool) {
+	if !isSensitivePipeline(config, pipeline) {
+		return nil, false
+	}
+
+	var violations []string
+	overridden := false
+	if len(status.Uncommitted) > 0 {
+		if allowDirtyFlag {
+			overridden = true
+		} else {
+			violations = append(violations, fmt.Sprintf("refusing to deploy %s with uncommitted changes, pass --allow-dirty to deploy anyway", pipeline.Name))
+		}
+	}
+	if !status.Pushed() {
+		if allowUnpushedFlag {
+			overridden = true
+		} else {
+			violations = append(violations, fmt.Sprintf("refusing to deploy %s with unpushed commits, pass --allow-unpushed to deploy anyway", pipeline.Name))
+		}
+	}
+	return violations, overridden
+}
+
+// productionPipeline matches pipeline names containing the word prod or production, but not e.g. product-sync
+var productionPipeline = regexp.MustCompile(`(?i)(^|[^a-z0-9])prod(uction)?([^a-z0-9]|$)`)
+
+// isSensitivePipeline reports whether the pipeline is protected or deploys to production
+func isSensitivePipeline(config Config, pipeline buddy.Pipeline) bool {
+	if config.Protected.Pipeline != "" && pipeline.Name == config.Protected.Pipeline {
+		return true
+	}
+	return productionPipeline.MatchString(pipeline.Name)
+}
+
+// pushMissingBranch offers to push a local branch Buddy answered 404 for, or pushes it right away with --push,
+// then waits until Buddy sees the branch. It fails when the branch is not pushed or never shows up.
+func pushMissingBranch(apiClient buddy.BuddyAPI, config Config, project, branch string) (*buddy.Branch, error) {
+	remote := config.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+
+	slog.Warn("branch not found in Buddy", "project", project, "branch", branch)
+
+	if !pushFlag {
+		push, err := confirmPush(remote, branch)
+		if err != nil {
+			return nil, err
+		}
+		if !push {
+			return nil, classify(errNotFound, fmt.Errorf("branch %s not found in project %s", branch, project))
+		}
+	}
+
+	if err := util.PushBranch(remote, branch); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Waiting for Buddy to see the branch...", "branch", branch)
+	deadline := time.Now().Add(branchWaitTimeout)
+	for {
+		branchFound, err := apiClient.FetchBranchByName(project, branch)
+		if err == nil {
+			return branchFound, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, classify(errNotFound, fmt.Errorf("branch %s was pushed but Buddy did not see it within %s", branch, branchWaitTimeout))
+		}
+		time.Sleep(branchPollInterval)
+	}
+}
+
+func confirmPush(remote, branch string) (bool, error) {
+	return prompter.Confirm(fmt.Sprintf("Push %s to %s and continue", branch, remote))
+}
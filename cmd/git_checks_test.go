@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/JacobAndrewSmith92/gobuddy/internal/util"
+)
+
+func TestIsSensitivePipeline(t *testing.T) {
+	config := Config{Protected: Protected{Pipeline: "Release"}}
+
+	tests := map[string]bool{
+		"Deploy to Production": true,
+		"prod-eu":              true,
+		"Deploy (PROD)":        true,
+		"Release":              true,
+		"product-sync":         false,
+		"Deploy to Staging":    false,
+		"reproduce":            false,
+	}
+	for name, want := range tests {
+		if got := isSensitivePipeline(config, buddy.Pipeline{Name: name}); got != want {
+			t.Errorf("isSensitivePipeline(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestDryRunRefusesUnsafeCurrentBranch(t *testing.T) {
+	var out bytes.Buffer
+	humanOut, dataOut = &out, &out
+	t.Cleanup(func() { humanOut, dataOut = os.Stdout, os.Stdout })
+
+	target := &deployTarget{
+		Project:  "api",
+		Branch:   "main",
+		Pipeline: buddy.Pipeline{ID: 12, Name: "Deploy to Production"},
+		Git:      &util.GitStatus{Uncommitted: []string{" M main.go"}, Upstream: "origin/main"},
+	}
+	err := printDryRun(buddy.NewBuddyClient("token", "acme"), Config{Workspace: "acme"}, []*deployTarget{target})
+	if !errors.Is(err, errProtection) {
+		t.Fatalf("err = %v, want a protection error", err)
+	}
+
+	allowDirtyFlag = true
+	t.Cleanup(func() { allowDirtyFlag = false })
+	if err := printDryRun(buddy.NewBuddyClient("token", "acme"), Config{Workspace: "acme"}, []*deployTarget{target}); err != nil {
+		t.Fatalf("--allow-dirty: err = %v, want the deployment allowed", err)
+	}
+}
+
+func TestGitSafetyReportsEveryViolation(t *testing.T) {
+	status := util.GitStatus{Uncommitted: []string{" M main.go"}, Upstream: "origin/main", Ahead: 2}
+	_, err := checkGitSafety(Config{}, buddy.Pipeline{Name: "Deploy to Production"}, status)
+	if !errors.Is(err, errProtection) {
+		t.Fatalf("err = %v, want a protection error", err)
+	}
+	for _, flag := range []string{"--allow-dirty", "--allow-unpushed"} {
+		if !strings.Contains(err.Error(), flag) {
+			t.Errorf("err = %v, want it to mention %s", err, flag)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/spf13/cobra"
+)
+
+// historyFilePath is the append-only audit log of deploy attempts, one JSON entry per line
+var historyFilePath = filepath.Join(configDir, "history.jsonl")
+
+// HistoryEntry is a single deploy attempt in the audit log
+type HistoryEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Command     string    `json:"command"`
+	User        string    `json:"user"`
+	Creator     string    `json:"creator,omitempty"`
+	Workspace   string    `json:"workspace"`
+	Project     string    `json:"project"`
+	Branch      string    `json:"branch"`
+	Pipeline    string    `json:"pipeline"`
+	PipelineID  int       `json:"pipeline_id"`
+	Revision    string    `json:"revision"`
+	ExecutionID int       `json:"execution_id,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	// Status is the last known status of the execution, or REFUSED, CANCELED, VETOED and TRIGGER_FAILED when nothing ran
+	Status string `json:"status"`
+	// ProtectionOverridden is set when an --allow-* flag was needed for the deploy to go through
+	ProtectionOverridden bool   `json:"protection_overridden"`
+	Error                string `json:"error,omitempty"`
+}
+
+var historyProjectFlag string
+var historyBranchFlag string
+var historyPipelineFlag string
+var historyStatusFlag string
+var historyUserFlag string
+var historySinceFlag time.Duration
+var historyLimitFlag int
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:         "history",
+	Short:       "Show the local deployment history",
+	Long:        `This command shows the deploy attempts recorded in ~/.gobuddy/history.jsonl, newest first. The history can be filtered and exported with --output json, yaml or csv.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{csvAnnotation: ""},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		entries, err := loadHistory()
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to load history: %w", err)
+		}
+
+		entries = filterHistory(entries)
+
+		if outputFlag == "csv" {
+			if err := writeHistoryCSV(entries); err != nil {
+				return fmt.Errorf("failed to write history: %w", err)
+			}
+			return nil
+		}
+		return printData(entries, func() { printHistoryTable(entries) })
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyProjectFlag, "project", "", "Only show deploys of this project")
+	historyCmd.Flags().StringVar(&historyBranchFlag, "branch", "", "Only show deploys of this branch")
+	historyCmd.Flags().StringVar(&historyPipelineFlag, "pipeline", "", "Only show deploys of this pipeline (name or ID)")
+	historyCmd.Flags().StringVar(&historyStatusFlag, "status", "", "Only show deploys with this status, e.g. SUCCESSFUL")
+	historyCmd.Flags().StringVar(&historyUserFlag, "user", "", "Only show deploys started by this OS user")
+	historyCmd.Flags().DurationVar(&historySinceFlag, "since", 0, "Only show deploys from the last duration, e.g. 72h")
+	historyCmd.Flags().IntVarP(&historyLimitFlag, "limit", "n", 0, "Maximum number of deploys to show")
+	rootCmd.AddCommand(historyCmd)
+}
+
+// newHistoryEntry describes a deploy attempt of a pipeline on a revision, before it is triggered
+func newHistoryEntry(command string, config Config, project, branch string, pipeline buddy.Pipeline, revision string) HistoryEntry {
+	return HistoryEntry{
+		Command:    command,
+		Workspace:  config.Workspace,
+		Project:    project,
+		Branch:     branch,
+		Pipeline:   pipeline.Name,
+		PipelineID: pipeline.ID,
+		Revision:   revision,
+	}
+}
+
+// recordTargets records the outcome of every target of a multi project deploy or plan and returns the recorded entries
+func recordTargets(command string, config Config, targets []*deployTarget) []HistoryEntry {
+	entries := []HistoryEntry{}
+	for _, target := range targets {
+		entry := newHistoryEntry(command, config, target.Project, target.Branch, target.Pipeline, target.Revision.Revision)
+		entry.ExecutionID = target.ExecutionID
+		entry.URL = target.URL
+		entry.Creator = target.Creator
+		entry.Status = target.Status
+		entry.ProtectionOverridden = target.ProtectionOverridden
+		if target.Err != nil {
+			entry.Error = target.Err.Error()
+		}
+		recordHistory(&entry)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// refuseDeployment records a deploy attempt refused by a protection rule or a git check and returns the refusal
+func refuseDeployment(entry HistoryEntry, err error) error {
+	entry.Status = "REFUSED"
+	entry.Error = err.Error()
+	recordHistory(&entry)
+	if printErr := printData([]HistoryEntry{entry}, nil); printErr != nil {
+		return printErr
+	}
+	return err
+}
+
+// recordHistory stamps a deploy attempt with the time and user and appends it to the audit log.
+// Failing to write the log only prints a warning, it never fails the deploy.
+func recordHistory(entry *HistoryEntry) {
+	entry.Timestamp = time.Now().UTC()
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Warn("unable to record deploy history", "error", err)
+		return
+	}
+
+	err = os.MkdirAll(configDir, 0700)
+	if err != nil {
+		slog.Warn("unable to record deploy history", "error", err)
+		return
+	}
+
+	file, err := os.OpenFile(historyFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Warn("unable to record deploy history", "error", err)
+		return
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		slog.Warn("unable to record deploy history", "error", err)
+	}
+}
+
+// loadHistory reads every entry of the audit log, newest first
+func loadHistory() ([]HistoryEntry, error) {
+	file, err := os.Open(historyFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt history entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.Reverse(entries)
+	return entries, scanner.Err()
+}
+
+// filterHistory keeps the entries matching the history flags
+func filterHistory(entries []HistoryEntry) []HistoryEntry {
+	filtered := []HistoryEntry{}
+	for _, entry := range entries {
+		if historyProjectFlag != "" && entry.Project != historyProjectFlag {
+			continue
+		}
+		if historyBranchFlag != "" && entry.Branch != historyBranchFlag {
+			continue
+		}
+		if historyPipelineFlag != "" && entry.Pipeline != historyPipelineFlag && strconv.Itoa(entry.PipelineID) != historyPipelineFlag {
+			continue
+		}
+		if historyStatusFlag != "" && !strings.EqualFold(entry.Status, historyStatusFlag) {
+			continue
+		}
+		if historyUserFlag != "" && entry.User != historyUserFlag {
+			continue
+		}
+		if historySinceFlag > 0 && time.Since(entry.Timestamp) > historySinceFlag {
+			continue
+		}
+		filtered = append(filtered, entry)
+		if historyLimitFlag > 0 && len(filtered) == historyLimitFlag {
+			break
+		}
+	}
+	return filtered
+}
+
+// printHistoryTable prints history entries as a table
+func printHistoryTable(entries []HistoryEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(humanOut, "No deployments recorded.")
+		return
+	}
+
+	writer := tabwriter.NewWriter(humanOut, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TIME\tUSER\tPROJECT\tBRANCH\tPIPELINE\tREVISION\tSTATUS")
+	for _, entry := range entries {
+		status := entry.Status
+		if entry.ProtectionOverridden {
+			status += " (overridden)"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Timestamp.Local().Format("2006-01-02 15:04"),
+			entry.User,
+			entry.Project,
+			entry.Branch,
+			entry.Pipeline,
+			shortRevision(entry.Revision),
+			status,
+		)
+	}
+	writer.Flush()
+}
+
+// writeHistoryCSV writes history entries as CSV with a header row
+func writeHistoryCSV(entries []HistoryEntry) error {
+	writer := csv.NewWriter(dataOut)
+	err := writer.Write([]string{"timestamp", "command", "user", "creator", "workspace", "project", "branch", "pipeline", "pipeline_id", "revision", "execution_id", "status", "protection_overridden", "error"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err := writer.Write([]string{
+			entry.Timestamp.Format(time.RFC3339),
+			entry.Command,
+			entry.User,
+			entry.Creator,
+			entry.Workspace,
+			entry.Project,
+			entry.Branch,
+			entry.Pipeline,
+			strconv.Itoa(entry.PipelineID),
+			entry.Revision,
+			strconv.Itoa(entry.ExecutionID),
+			entry.Status,
+			strconv.FormatBool(entry.ProtectionOverridden),
+			entry.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
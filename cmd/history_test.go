@@ -0,0 +1,14 @@
+package cmd
+
+import "testing"
+
+func TestFilterHistoryStatusMatchesExactly(t *testing.T) {
+	historyStatusFlag = "failed"
+	t.Cleanup(func() { historyStatusFlag = "" })
+
+	entries := []HistoryEntry{{Project: "api", Status: "FAILED"}, {Project: "web", Status: "TRIGGER_FAILED"}}
+	filtered := filterHistory(entries)
+	if len(filtered) != 1 || filtered[0].Project != "api" {
+		t.Errorf("filterHistory() = %+v, want only the FAILED entry of api", filtered)
+	}
+}
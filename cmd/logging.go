@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+var verboseFlag bool
+var quietFlag bool
+var logFormatFlag string
+
+// logLevel is the level of the default logger, info unless --verbose or --quiet is passed
+var logLevel = new(slog.LevelVar)
+
+func init() {
+	slog.SetDefault(slog.New(newTextHandler(os.Stderr, logLevel)))
+}
+
+// setupLogging validates the logging flags and configures the default logger.
+// Logs are always written to stderr.
+func setupLogging() error {
+	if verboseFlag && quietFlag {
+		return classify(errConfig, errors.New("--verbose and --quiet can't be combined"))
+	}
+	switch {
+	case verboseFlag:
+		logLevel.Set(slog.LevelDebug)
+	case quietFlag:
+		logLevel.Set(slog.LevelWarn)
+	}
+
+	switch logFormatFlag {
+	case "text":
+		slog.SetDefault(slog.New(newTextHandler(os.Stderr, logLevel)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+	default:
+		return classify(errConfig, fmt.Errorf("invalid log format: %s. Use 'text' or 'json'", logFormatFlag))
+	}
+	return nil
+}
+
+// textHandler writes log records for humans: the message followed by its attributes as key=value,
+// without timestamps. Warnings, errors and debug messages are prefixed with their level.
+type textHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	// attrs holds the attributes added with WithAttrs, already formatted
+	attrs string
+	// prefix is prepended to attribute keys inside groups opened with WithGroup
+	prefix string
+}
+
+func newTextHandler(w io.Writer, level slog.Leveler) *textHandler {
+	return &textHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	switch {
+	case record.Level >= slog.LevelError:
+		line.WriteString(color.New(color.FgRed).Sprint("Error: " + record.Message))
+	case record.Level >= slog.LevelWarn:
+		line.WriteString(color.New(color.FgYellow).Sprint("Warning: " + record.Message))
+	case record.Level < slog.LevelInfo:
+		line.WriteString(color.New(color.Faint).Sprint("Debug: " + record.Message))
+	default:
+		line.WriteString(record.Message)
+	}
+
+	line.WriteString(h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		writeAttr(&line, h.prefix, attr)
+		return true
+	})
+	line.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var line strings.Builder
+	for _, attr := range attrs {
+		writeAttr(&line, h.prefix, attr)
+	}
+	handler := *h
+	handler.attrs += line.String()
+	return &handler
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handler := *h
+	handler.prefix += name + "."
+	return &handler
+}
+
+// writeAttr writes an attribute as key=value, the attributes of groups are written with the group name as prefix
+func writeAttr(line *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			writeAttr(line, prefix, groupAttr)
+		}
+		return
+	}
+
+	value := attr.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(line, " %s=%s", prefix+attr.Key, color.New(color.FgCyan).Sprint(value))
+}
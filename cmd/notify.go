@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+)
+
+// webhookTimeout is how long a webhook may take to accept a notification
+const webhookTimeout = 10 * time.Second
+
+// Notification is the generic JSON payload posted to webhooks once an execution finished
+type Notification struct {
+	Workspace       string `json:"workspace"`
+	Project         string `json:"project"`
+	Branch          string `json:"branch"`
+	Pipeline        string `json:"pipeline"`
+	PipelineID      int    `json:"pipeline_id"`
+	Revision        string `json:"revision"`
+	ExecutionID     int    `json:"execution_id"`
+	Status          string `json:"status"`
+	StartDate       string `json:"start_date"`
+	FinishDate      string `json:"finish_date"`
+	DurationSeconds int    `json:"duration_seconds"`
+	Creator         string `json:"creator"`
+	HTMLURL         string `json:"html_url"`
+}
+
+// webhookTemplate returns the payload format of a webhook
+func webhookTemplate(webhook Webhook) string {
+	if webhook.Template == "" {
+		return "json"
+	}
+	return webhook.Template
+}
+
+// notifyWebhooks posts the finished execution to every configured webhook.
+// Failures are reported as warnings and never fail the deploy.
+func notifyWebhooks(apiClient buddy.BuddyAPI, config Config, project string, pipelineID, executionID int) {
+	if len(config.Webhooks) == 0 {
+		return
+	}
+
+	execution, err := apiClient.FetchExecution(project, pipelineID, executionID)
+	if err != nil {
+		slog.Warn("unable to send notifications", "error", err)
+		return
+	}
+
+	notification := newNotification(config, project, *execution)
+	client := &http.Client{Timeout: webhookTimeout}
+
+	for _, webhook := range config.Webhooks {
+		var payload any = notification
+		if webhookTemplate(webhook) == "slack" {
+			payload = slackPayload(notification)
+		}
+
+		err := postWebhook(client, webhook.URL, payload)
+		if err != nil {
+			slog.Warn("unable to notify webhook", "url", webhook.URL, "error", err)
+		}
+	}
+}
+
+// newNotification builds the notification of a finished execution
+func newNotification(config Config, project string, execution buddy.PipelineExecutionResponse) Notification {
+	notification := Notification{
+		Workspace:   config.Workspace,
+		Project:     project,
+		Branch:      execution.Branch.Name,
+		Pipeline:    execution.Pipeline.Name,
+		PipelineID:  execution.Pipeline.ID,
+		Revision:    execution.ToRevision.Revision,
+		ExecutionID: execution.ID,
+		Status:      execution.Status,
+		StartDate:   execution.StartDate,
+		Creator:     execution.Creator.Name,
+		HTMLURL:     execution.HTMLURL,
+	}
+
+	if execution.FinishDate != nil {
+		notification.FinishDate = *execution.FinishDate
+		started, startErr := time.Parse(time.RFC3339, execution.StartDate)
+		finished, finishErr := time.Parse(time.RFC3339, *execution.FinishDate)
+		if startErr == nil && finishErr == nil {
+			notification.DurationSeconds = int(finished.Sub(started).Seconds())
+		}
+	}
+
+	return notification
+}
+
+// slackPayload formats a notification as a Slack incoming webhook message
+func slackPayload(notification Notification) map[string]any {
+	colors := map[string]string{"SUCCESSFUL": "good", "FAILED": "danger", "TERMINATED": "danger"}
+	barColor, ok := colors[notification.Status]
+	if !ok {
+		barColor = "warning"
+	}
+
+	field := func(title, value string) map[string]any {
+		return map[string]any{"title": title, "value": value, "short": true}
+	}
+
+	return map[string]any{
+		"text": fmt.Sprintf("%s of %s on %s: *%s*", notification.Pipeline, notification.Project, notification.Branch, notification.Status),
+		"attachments": []map[string]any{
+			{
+				"color":      barColor,
+				"title":      fmt.Sprintf("Execution #%d", notification.ExecutionID),
+				"title_link": notification.HTMLURL,
+				"fields": []map[string]any{
+					field("Project", notification.Project),
+					field("Branch", notification.Branch),
+					field("Pipeline", notification.Pipeline),
+					field("Revision", shortRevision(notification.Revision)),
+					field("Status", notification.Status),
+					field("Duration", (time.Duration(notification.DurationSeconds) * time.Second).String()),
+					field("Creator", notification.Creator),
+				},
+			},
+		},
+	}
+}
+
+// postWebhook posts a JSON payload to a webhook URL
+func postWebhook(client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// notifyTargets notifies the webhooks of every finished target of a multi project deploy or plan
+func notifyTargets(apiClient buddy.BuddyAPI, config Config, targets []*deployTarget) {
+	for _, target := range targets {
+		if target.ExecutionID != 0 && isFinalStatus(target.Status) {
+			notifyWebhooks(apiClient, config, target.Project, target.Pipeline.ID, target.ExecutionID)
+		}
+	}
+}
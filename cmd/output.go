@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// outputFlag selects how command data is printed: table, json, yaml or template=<go-template>
+var outputFlag string
+
+// dataOut receives command data
+var dataOut io.Writer = os.Stdout
+
+// humanOut receives messages, tables and prompts meant for humans. With a machine readable output
+// format it is stderr, so stdout only carries data.
+var humanOut io.Writer = os.Stdout
+
+// csvAnnotation marks the commands able to print their data as CSV
+const csvAnnotation = "output-csv"
+
+// setupOutput validates --output for cmd before it runs and moves everything but command data to stderr
+// when the output is meant for machines
+func setupOutput(cmd *cobra.Command) error {
+	switch {
+	case outputFlag == "table", outputFlag == "json", outputFlag == "yaml":
+	case outputFlag == "csv":
+		if _, ok := cmd.Annotations[csvAnnotation]; !ok {
+			return classify(errConfig, fmt.Errorf("output format csv is not supported by %s, only by history", cmd.CommandPath()))
+		}
+	case strings.HasPrefix(outputFlag, "template="):
+		if _, err := template.New("output").Parse(strings.TrimPrefix(outputFlag, "template=")); err != nil {
+			return classify(errConfig, fmt.Errorf("invalid output template: %v", err))
+		}
+	default:
+		return classify(errConfig, fmt.Errorf("invalid output format: %s. Use 'table', 'json', 'yaml', 'csv' or 'template=<go-template>'", outputFlag))
+	}
+
+	if outputFlag == "table" {
+		return nil
+	}
+	humanOut = os.Stderr
+	return nil
+}
+
+// printData writes command data in the selected output format. table prints the data for humans,
+// it is nil for commands that already reported everything while running.
+// Templates are executed on the JSON form of the data, so they use the documented JSON field names.
+func printData(data any, table func()) error {
+	if outputFlag == "table" {
+		if table != nil {
+			table()
+		}
+		return nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %v", err)
+	}
+
+	switch {
+	case outputFlag == "json":
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, encoded, "", "  "); err != nil {
+			return fmt.Errorf("failed to format output: %v", err)
+		}
+		_, err = fmt.Fprintln(dataOut, indented.String())
+		return err
+	case outputFlag == "yaml":
+		generic, err := genericData(encoded)
+		if err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(dataOut)
+		encoder.SetIndent(2)
+		return encoder.Encode(yamlNumbers(generic))
+	case strings.HasPrefix(outputFlag, "template="):
+		generic, err := genericData(encoded)
+		if err != nil {
+			return err
+		}
+		tmpl := template.Must(template.New("output").Parse(strings.TrimPrefix(outputFlag, "template=")))
+		if err := tmpl.Execute(dataOut, generic); err != nil {
+			return fmt.Errorf("failed to execute output template: %v", err)
+		}
+		_, err = fmt.Fprintln(dataOut)
+		return err
+	default:
+		return classify(errConfig, fmt.Errorf("output format %s is not supported by this command", outputFlag))
+	}
+}
+
+// genericData decodes JSON into maps and slices, keeping numbers exact
+func genericData(encoded []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to format output: %v", err)
+	}
+	return generic, nil
+}
+
+// yamlNumbers replaces JSON numbers with integers or floats, YAML would quote them as strings otherwise
+func yamlNumbers(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = yamlNumbers(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = yamlNumbers(item)
+		}
+	case json.Number:
+		if integer, err := value.Int64(); err == nil {
+			return integer
+		}
+		float, _ := value.Float64()
+		return float
+	}
+	return value
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix is the prefix of plugin executables, gobuddy-foo on PATH becomes `gobuddy foo`
+const pluginPrefix = "gobuddy-"
+
+// pluginGroup lists plugins separately from the built-in commands in the help
+const pluginGroup = "plugins"
+
+// pluginExitError carries the exit code of a plugin, the plugin reported the error itself
+type pluginExitError struct {
+	name string
+	code int
+}
+
+func (e *pluginExitError) Error() string {
+	return fmt.Sprintf("plugin %s exited with status %d", e.name, e.code)
+}
+
+// registerPlugins adds a subcommand for every gobuddy-<name> executable on PATH.
+// The first executable of a name on PATH wins, and plugins can't replace built-in commands.
+func registerPlugins() {
+	plugins := findPlugins()
+	if len(plugins) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rootCmd.AddGroup(&cobra.Group{ID: pluginGroup, Title: "Plugin Commands:"})
+	for _, name := range names {
+		if isBuiltinCommand(name) {
+			continue
+		}
+		rootCmd.AddCommand(newPluginCommand(name, plugins[name]))
+	}
+}
+
+// findPlugins maps plugin names to the path of their executable
+func findPlugins() map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || plugins[name] != "" {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if isExecutable(path) {
+				plugins[name] = path
+			}
+		}
+	}
+	return plugins
+}
+
+// pluginName returns the command name of a plugin executable
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, pluginPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(file, pluginPrefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name, name != ""
+}
+
+// isExecutable reports whether path is a file the user can execute
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return info.Mode().Perm()&0111 != 0
+}
+
+// isBuiltinCommand reports whether name is taken by a command of gobuddy itself
+func isBuiltinCommand(name string) bool {
+	for _, command := range rootCmd.Commands() {
+		if command.Name() == name || command.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help" || name == "completion"
+}
+
+// newPluginCommand runs the plugin with every argument after its name, flags included
+func newPluginCommand(name, path string) *cobra.Command {
+	return &cobra.Command{
+		Use:                name,
+		Short:              fmt.Sprintf("Plugin %s", path),
+		GroupID:            pluginGroup,
+		DisableFlagParsing: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runPlugin(name, path, args)
+		},
+	}
+}
+
+// runPlugin runs a plugin with the resolved configuration in its environment.
+// The plugin gets interrupts from the terminal itself, gobuddy only catches them to keep running
+// until the plugin decides how to stop. signal.Ignore would be inherited by the plugin.
+func runPlugin(name, path string, args []string) error {
+	plugin := exec.Command(path, args...)
+	plugin.Stdin = os.Stdin
+	plugin.Stdout = os.Stdout
+	plugin.Stderr = os.Stderr
+	plugin.Env = append(os.Environ(), pluginEnv()...)
+
+	interrupts := notifyInterrupts()
+	defer signal.Stop(interrupts)
+
+	if err := plugin.Start(); err != nil {
+		return fmt.Errorf("unable to run plugin %s: %w", name, err)
+	}
+
+	err := plugin.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			code = exitError
+		}
+		return &pluginExitError{name: name, code: code}
+	}
+	return err
+}
+
+// pluginEnv describes the resolved configuration to plugins. The token and workspace are empty
+// when gobuddy is not configured yet.
+func pluginEnv() []string {
+	config, _ := loadConfig()
+	return []string{
+		"GOBUDDY_CONFIG=" + configFilePath,
+		"GOBUDDY_TOKEN=" + config.Token,
+		"GOBUDDY_WORKSPACE=" + config.Workspace,
+		"GOBUDDY_API_URL=" + buddy.APIBaseURL,
+	}
+}
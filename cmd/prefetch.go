@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/JacobAndrewSmith92/gobuddy/internal/util"
+)
+
+// projectPrefetch fetches the branches and pipelines of a project concurrently in the background,
+// so they are ready once the interactive flow needs them
+type projectPrefetch struct {
+	project string
+
+	branches      []buddy.Branch
+	branchesErr   error
+	branchesDone  chan struct{}
+	pipelines     []buddy.Pipeline
+	pipelinesErr  error
+	pipelinesDone chan struct{}
+}
+
+// prefetchProject starts fetching the branches and pipelines of project. Only the lists the deploy
+// still has to pick from are fetched, the others are reported as empty.
+func prefetchProject(apiClient buddy.BuddyAPI, project string, branches, pipelines bool) *projectPrefetch {
+	prefetch := &projectPrefetch{
+		project:       project,
+		branchesDone:  make(chan struct{}),
+		pipelinesDone: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(prefetch.branchesDone)
+		if branches {
+			prefetch.branches, prefetch.branchesErr = apiClient.FetchBranches(project)
+		}
+	}()
+	go func() {
+		defer close(prefetch.pipelinesDone)
+		if pipelines {
+			prefetch.pipelines, prefetch.pipelinesErr = apiClient.FetchPipelines(project)
+		}
+	}()
+
+	return prefetch
+}
+
+// Branches waits for the branches of the project
+func (p *projectPrefetch) Branches() ([]buddy.Branch, error) {
+	<-p.branchesDone
+	return p.branches, p.branchesErr
+}
+
+// Pipelines waits for the pipelines of the project
+func (p *projectPrefetch) Pipelines() ([]buddy.Pipeline, error) {
+	<-p.pipelinesDone
+	return p.pipelines, p.pipelinesErr
+}
+
+// speculativeProject returns the project named like the current directory, the one most likely to be picked,
+// or "" when there is none
+func speculativeProject(projects []buddy.Project) string {
+	directory, err := util.GetCurrentDirectoryName()
+	if err != nil {
+		return ""
+	}
+	for _, project := range projects {
+		if project.Name == directory {
+			return project.Name
+		}
+	}
+	return ""
+}
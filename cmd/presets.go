@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// presetPrefix marks a preset in place of the project, `gobuddy deploy @ship-api`
+const presetPrefix = "@"
+
+// presetBranchCurrent deploys the current Git branch, like --current
+const presetBranchCurrent = "current"
+
+// presetGroup lists the preset alias commands separately in the help
+const presetGroup = "presets"
+
+// Preset is a named set of deploy flags, e.g. "ship-api": {"project": "api", "pipeline": "Deploy to Staging", "branch": "current", "wait": true}
+type Preset struct {
+	Project string `json:"project,omitempty"`
+	// Group deploys every project of a project group instead of a single project
+	Group string `json:"group,omitempty"`
+	// Branch is a branch name, or "current" for the current Git branch
+	Branch string `json:"branch,omitempty"`
+	// Pipeline is a pipeline name or ID
+	Pipeline string `json:"pipeline,omitempty"`
+	// Wait follows the execution until it finished without asking
+	Wait bool `json:"wait,omitempty"`
+}
+
+// applyPreset replaces a @preset argument with the preset's project and sets the deploy flags of the preset.
+// Flags passed on the command line override the preset.
+func applyPreset(cmd *cobra.Command, config Config, args []string) ([]string, error) {
+	if len(args) == 0 || !strings.HasPrefix(args[0], presetPrefix) {
+		return args, nil
+	}
+
+	name := strings.TrimPrefix(args[0], presetPrefix)
+	preset, ok := config.Presets[name]
+	if !ok {
+		return nil, classify(errConfig, fmt.Errorf("preset %s not found in configuration", name))
+	}
+	if len(args) > 1 {
+		return nil, classify(errConfig, fmt.Errorf("preset %s can't be combined with other projects", name))
+	}
+
+	args = nil
+	if preset.Project != "" {
+		args = []string{preset.Project}
+	}
+	if preset.Group != "" && !cmd.Flags().Changed("group") {
+		groupFlag = preset.Group
+	}
+	if preset.Pipeline != "" && !cmd.Flags().Changed("pipeline") {
+		pipelineFlag = preset.Pipeline
+	}
+	if !cmd.Flags().Changed("branch") && !cmd.Flags().Changed("current") {
+		if preset.Branch == presetBranchCurrent {
+			currentFlag = true
+		} else if preset.Branch != "" {
+			branchFlag = preset.Branch
+		}
+	}
+	if preset.Wait && !cmd.Flags().Changed("wait") {
+		waitFlag = true
+	}
+	return args, nil
+}
+
+// registerPresets adds an alias command for every preset, `gobuddy ship-api` runs `gobuddy deploy @ship-api`.
+// Presets named like a built-in or plugin command are only available as @name.
+func registerPresets() {
+	config, err := loadConfig()
+	if err != nil || len(config.Presets) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(config.Presets))
+	for name := range config.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rootCmd.AddGroup(&cobra.Group{ID: presetGroup, Title: "Preset Commands:"})
+	for _, name := range names {
+		if isBuiltinCommand(name) {
+			continue
+		}
+		rootCmd.AddCommand(newPresetCommand(name, config.Presets[name]))
+	}
+}
+
+// newPresetCommand deploys a preset, it accepts the flags of deploy to override the preset
+func newPresetCommand(name string, preset Preset) *cobra.Command {
+	command := &cobra.Command{
+		Use:     name,
+		Short:   fmt.Sprintf("Deploy preset: %s", describePreset(preset)),
+		GroupID: presetGroup,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return deployCmd.RunE(cmd, []string{presetPrefix + name})
+		},
+	}
+	command.Flags().AddFlagSet(deployCmd.Flags())
+	return command
+}
+
+// describePreset summarizes a preset as key=value pairs, the format config set preset.<name> takes
+func describePreset(preset Preset) string {
+	var parts []string
+	if preset.Project != "" {
+		parts = append(parts, "project="+preset.Project)
+	}
+	if preset.Group != "" {
+		parts = append(parts, "group="+preset.Group)
+	}
+	if preset.Branch != "" {
+		parts = append(parts, "branch="+preset.Branch)
+	}
+	if preset.Pipeline != "" {
+		parts = append(parts, "pipeline="+preset.Pipeline)
+	}
+	if preset.Wait {
+		parts = append(parts, "wait=true")
+	}
+	return strings.Join(parts, ",")
+}
+
+// setPreset stores a preset given as comma separated key=value pairs, e.g. "project=api,branch=current,wait=true".
+// An empty value removes the preset.
+func setPreset(config *Config, name, value string) error {
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	if strings.TrimSpace(value) == "" {
+		delete(config.Presets, name)
+		fmt.Fprintf(humanOut, "Preset %s removed\n", yellow(name))
+		return nil
+	}
+
+	var preset Preset
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return classify(errConfig, fmt.Errorf("invalid preset setting %q, use key=value", pair))
+		}
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		switch key {
+		case "project":
+			preset.Project = val
+		case "group":
+			preset.Group = val
+		case "branch":
+			preset.Branch = val
+		case "pipeline":
+			preset.Pipeline = val
+		case "wait":
+			wait, err := strconv.ParseBool(val)
+			if err != nil {
+				return classify(errConfig, fmt.Errorf("invalid wait %q in preset %s: %w", val, name, err))
+			}
+			preset.Wait = wait
+		default:
+			return classify(errConfig, fmt.Errorf("invalid preset key %s. Use project, group, branch, pipeline or wait", key))
+		}
+	}
+	if preset.Project != "" && preset.Group != "" {
+		return classify(errConfig, fmt.Errorf("preset %s can't have both a project and a group", name))
+	}
+
+	if config.Presets == nil {
+		config.Presets = map[string]Preset{}
+	}
+	config.Presets[name] = preset
+	fmt.Fprintf(humanOut, "Preset %s updated to: %s\n", yellow(name), yellow(describePreset(preset)))
+	return nil
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/spf13/cobra"
+)
+
+// defaultSoakTime is used when neither --soak nor soak_time in the configuration are set
+const defaultSoakTime = 24 * time.Hour
+
+var fromFlag string
+var toFlag string
+var soakFlag time.Duration
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote [project]",
+	Short: "Run a pipeline on the revision last deployed by another pipeline",
+	Long: `This command looks up the last execution of the --from pipeline and triggers the --to pipeline on the same branch and revision.
+Promotion is refused when that execution was not successful or finished longer ago than the soak time.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project := args[0]
+		config, err := requireConfig()
+		if err != nil {
+			return err
+		}
+
+		soakTime := defaultSoakTime
+		if cmd.Flags().Changed("soak") {
+			soakTime = soakFlag
+		} else if config.SoakTime != "" {
+			soakTime, err = time.ParseDuration(config.SoakTime)
+			if err != nil {
+				return classify(errConfig, fmt.Errorf("invalid soak time %s in configuration: %w", config.SoakTime, err))
+			}
+		}
+
+		apiClient := newAPIClient(config, false)
+
+		runHooksOrWarn(loadHooks(config).BeforeSelection, HookEvent{Hook: "before_selection", Command: "promote", Workspace: config.Workspace, Project: project})
+
+		slog.Info("Looking up project", "project", project)
+		if _, err := apiClient.FetchProjectByName(project); err != nil {
+			return err
+		}
+
+		from, err := findPipeline(apiClient, project, fromFlag)
+		if err != nil {
+			return err
+		}
+		to, err := findPipeline(apiClient, project, toFlag)
+		if err != nil {
+			return err
+		}
+
+		executions, err := apiClient.FetchExecutions(project, from.ID)
+		if err != nil {
+			return err
+		}
+
+		source := latestFinishedExecution(executions)
+		if source == nil {
+			return classify(errNotFound, fmt.Errorf("pipeline %s has no finished executions to promote", from.Name))
+		}
+
+		branch := source.Branch.Name
+		revision := source.ToRevision.Revision
+		entry := newHistoryEntry("promote", config, project, branch, *to, revision)
+
+		if source.Status != "SUCCESSFUL" {
+			return refuseDeployment(entry, classify(errProtection, fmt.Errorf("last execution of %s is %s, only successful executions can be promoted", from.Name, source.Status)))
+		}
+
+		age, err := executionAge(*source)
+		if err != nil {
+			return err
+		}
+		if age > soakTime {
+			return refuseDeployment(entry, classify(errProtection, fmt.Errorf("last execution of %s finished %s ago, older than the soak time of %s", from.Name, age.Round(time.Minute), soakTime)))
+		}
+
+		if !pipelineMatchesBranch(*to, branch) {
+			slog.Warn("branch does not match the refs of the pipeline", "branch", branch, "pipeline", to.Name, "refs", formatRefs(to.Refs))
+		}
+
+		if err := checkProtection(config, *to, branch); err != nil {
+			return refuseDeployment(entry, err)
+		}
+
+		slog.Info("Promoting project", "project", project, "branch", branch)
+		slog.Info("From pipeline", "pipeline", from.Name, "pipeline_id", from.ID, "execution_id", source.ID, "finished_ago", age.Round(time.Minute))
+		slog.Info("To pipeline", "pipeline", to.Name, "pipeline_id", to.ID)
+		slog.Info("Revision to deploy", "revision", shortRevision(revision), "subject", firstLine(source.ToRevision.Message))
+
+		showChangelog(apiClient, project, *to, revision)
+
+		return runDeployment(apiClient, config, entry)
+	},
+}
+
+func init() {
+	promoteCmd.Flags().StringVar(&fromFlag, "from", "", "Pipeline (name or ID) whose last execution is promoted")
+	promoteCmd.Flags().StringVar(&toFlag, "to", "", "Pipeline (name or ID) to run on the promoted revision")
+	promoteCmd.Flags().DurationVar(&soakFlag, "soak", defaultSoakTime, "Refuse to promote executions that finished longer ago than this, overrides soak_time from the configuration")
+	promoteCmd.MarkFlagRequired("from")
+	promoteCmd.MarkFlagRequired("to")
+	promoteCmd.Flags().BoolVar(&cancelOnInterruptFlag, "cancel-on-interrupt", false, "Cancel the execution instead of detaching when interrupted while waiting")
+	promoteCmd.ValidArgsFunction = completeFirstProject
+	promoteCmd.RegisterFlagCompletionFunc("from", completePipelines)
+	promoteCmd.RegisterFlagCompletionFunc("to", completePipelines)
+	rootCmd.AddCommand(promoteCmd)
+}
+
+// latestFinishedExecution returns the newest execution that is no longer running
+func latestFinishedExecution(executions []buddy.PipelineExecutionResponse) *buddy.PipelineExecutionResponse {
+	for i := range executions {
+		if isFinalStatus(executions[i].Status) {
+			return &executions[i]
+		}
+	}
+	return nil
+}
+
+// lastSuccessfulExecution returns the newest successful execution
+func lastSuccessfulExecution(executions []buddy.PipelineExecutionResponse) *buddy.PipelineExecutionResponse {
+	for i := range executions {
+		if executions[i].Status == "SUCCESSFUL" {
+			return &executions[i]
+		}
+	}
+	return nil
+}
+
+// executionAge returns how long ago the execution finished, or started when it has no finish date
+func executionAge(execution buddy.PipelineExecutionResponse) (time.Duration, error) {
+	date := execution.StartDate
+	if execution.FinishDate != nil && *execution.FinishDate != "" {
+		date = *execution.FinishDate
+	}
+
+	finished, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse date of execution %d: %v", execution.ID, err)
+	}
+	return time.Since(finished), nil
+}
+
+// shortRevision shortens a commit SHA for display
+func shortRevision(revision string) string {
+	if len(revision) > 7 {
+		return revision[:7]
+	}
+	return revision
+}
+
+// firstLine returns the subject line of a commit message
+func firstLine(message string) string {
+	subject, _, _ := strings.Cut(message, "\n")
+	return subject
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// Prompter asks the user for input, every interactive flow goes through it
+type Prompter interface {
+	// Select asks to pick one of the items and returns its index
+	Select(prompt SelectPrompt) (int, error)
+	// Confirm asks a yes/no question
+	Confirm(label string) (bool, error)
+	// Input asks for a line of text, an empty answer returns def. Secret input is masked when possible.
+	Input(label, def string, secret bool) (string, error)
+}
+
+// SelectPrompt describes a choice between items
+type SelectPrompt struct {
+	Label string
+	Items []string
+	// Notes are shown next to the items and Details below the active item, or below every item
+	// in the numbered list, both are optional
+	Notes   []string
+	Details []string
+	// Color of the items in the full screen prompt, e.g. cyan
+	Color string
+	// Search starts the full screen prompt in search mode
+	Search bool
+}
+
+// prompter is used by every interactive flow. setupPrompter picks the implementation
+// unless one was injected before the command runs, e.g. by tests.
+var prompter Prompter
+
+// setupPrompter reads plain lines from stdin in the accessible mode and on dumb or non-interactive terminals,
+// and shows full screen prompts otherwise
+func setupPrompter() {
+	if prompter != nil {
+		return
+	}
+	if accessibleFlag || os.Getenv("TERM") == "dumb" || !isInteractive() {
+		prompter = newLinePrompter(os.Stdin)
+		return
+	}
+	prompter = promptuiPrompter{}
+}
+
+// promptuiPrompter shows full screen prompts with promptui
+type promptuiPrompter struct{}
+
+// selectItem is the display model of an item in the promptui select
+type selectItem struct {
+	Name    string
+	Note    string
+	Details string
+}
+
+func (promptuiPrompter) Select(prompt SelectPrompt) (int, error) {
+	items := make([]selectItem, len(prompt.Items))
+	for i, name := range prompt.Items {
+		items[i] = selectItem{Name: name, Note: itemAt(prompt.Notes, i), Details: itemAt(prompt.Details, i)}
+	}
+
+	color := prompt.Color
+	if color == "" {
+		color = "cyan"
+	}
+
+	selectPrompt := promptui.Select{
+		Label:  prompt.Label,
+		Items:  items,
+		Stdout: nopWriteCloser{humanOut},
+		Searcher: func(input string, index int) bool {
+			return containsIgnoreCase(items[index].Name, input)
+		},
+		StartInSearchMode: prompt.Search,
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ . | bold }}",
+			Active:   fmt.Sprintf(`▸ {{ .Name | %s | bold }} {{ .Note | yellow }}`, color),
+			Inactive: fmt.Sprintf(`  {{ .Name | %s }} {{ .Note | yellow }}`, color),
+			Selected: fmt.Sprintf(`✔  {{ .Name | %s | bold }}`, color),
+			Details:  "{{ if .Details }}\n{{ .Details }}{{ end }}",
+		},
+	}
+
+	i, _, err := selectPrompt.Run()
+	if err != nil {
+		return 0, fmt.Errorf("prompt failed: %w", err)
+	}
+	return i, nil
+}
+
+func (promptuiPrompter) Confirm(label string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:    label + " (yes/no)",
+		Validate: validateYesNo,
+		Stdout:   nopWriteCloser{humanOut},
+	}
+
+	result, err := prompt.Run()
+	if err != nil {
+		return false, fmt.Errorf("prompt failed: %w", err)
+	}
+	return strings.ToLower(result) == "yes", nil
+}
+
+func (promptuiPrompter) Input(label, def string, secret bool) (string, error) {
+	prompt := promptui.Prompt{
+		Label:   label,
+		Default: def,
+		Stdout:  nopWriteCloser{humanOut},
+	}
+	if secret {
+		prompt.Mask = '*'
+	}
+
+	result, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed: %w", err)
+	}
+	return result, nil
+}
+
+// nopWriteCloser lets promptui write to humanOut without ever closing it
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// linePrompter asks plain questions and reads whole lines, for terminals promptui can't drive
+type linePrompter struct {
+	in *bufio.Reader
+}
+
+func newLinePrompter(in io.Reader) *linePrompter {
+	return &linePrompter{in: bufio.NewReader(in)}
+}
+
+func (p *linePrompter) Select(prompt SelectPrompt) (int, error) {
+	fmt.Fprintln(humanOut, prompt.Label+":")
+	for i, item := range prompt.Items {
+		fmt.Fprintln(humanOut, strings.TrimRight(fmt.Sprintf("  %d) %s %s", i+1, item, itemAt(prompt.Notes, i)), " "))
+		if details := itemAt(prompt.Details, i); details != "" {
+			for _, line := range strings.Split(details, "\n") {
+				fmt.Fprintln(humanOut, "     "+line)
+			}
+		}
+	}
+
+	for {
+		answer, err := p.ask("Enter a number or name: ")
+		if err != nil {
+			return 0, err
+		}
+		i, err := matchItem(prompt.Items, answer)
+		if err != nil {
+			fmt.Fprintln(humanOut, err)
+			continue
+		}
+		return i, nil
+	}
+}
+
+func (p *linePrompter) Confirm(label string) (bool, error) {
+	for {
+		answer, err := p.ask(label + " (yes/no): ")
+		if err != nil {
+			return false, err
+		}
+		if err := validateYesNo(answer); err != nil {
+			fmt.Fprintln(humanOut, err)
+			continue
+		}
+		return strings.ToLower(answer) == "yes", nil
+	}
+}
+
+func (p *linePrompter) Input(label, def string, _ bool) (string, error) {
+	answer, err := p.ask(label + ": ")
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// ask prints the question and reads the answer
+func (p *linePrompter) ask(question string) (string, error) {
+	fmt.Fprint(humanOut, question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", fmt.Errorf("prompt failed: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// matchItem finds the item an answer picks: its 1-based number, its name, or the only name containing the answer
+func matchItem(items []string, answer string) (int, error) {
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(items) {
+			return 0, fmt.Errorf("please enter a number between 1 and %d", len(items))
+		}
+		return n - 1, nil
+	}
+
+	var matches []int
+	for i, item := range items {
+		if strings.EqualFold(item, answer) {
+			return i, nil
+		}
+		if containsIgnoreCase(item, answer) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("nothing matches %q", answer)
+	case 1:
+		return matches[0], nil
+	default:
+		return 0, fmt.Errorf("%d items match %q, be more specific", len(matches), answer)
+	}
+}
+
+// validateYesNo accepts yes or no in any case
+func validateYesNo(input string) error {
+	if strings.ToLower(input) != "yes" && strings.ToLower(input) != "no" {
+		return fmt.Errorf("please type 'yes' or 'no'")
+	}
+	return nil
+}
+
+// itemAt returns the i-th element of an optional list
+func itemAt(list []string, i int) string {
+	if i < len(list) {
+		return list[i]
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// scriptedPrompter answers prompts from a list of answers, failing on the first answer that doesn't fit
+type scriptedPrompter struct {
+	answers []string
+}
+
+func newScriptedPrompter(answers []string) *scriptedPrompter {
+	return &scriptedPrompter{answers: answers}
+}
+
+func (p *scriptedPrompter) Select(prompt SelectPrompt) (int, error) {
+	answer, err := p.next(prompt.Label)
+	if err != nil {
+		return 0, err
+	}
+	i, err := matchItem(prompt.Items, answer)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", prompt.Label, err)
+	}
+	return i, nil
+}
+
+func (p *scriptedPrompter) Confirm(label string) (bool, error) {
+	answer, err := p.next(label)
+	if err != nil {
+		return false, err
+	}
+	if err := validateYesNo(answer); err != nil {
+		return false, fmt.Errorf("%s: %w", label, err)
+	}
+	return strings.ToLower(answer) == "yes", nil
+}
+
+func (p *scriptedPrompter) Input(label, def string, _ bool) (string, error) {
+	answer, err := p.next(label)
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// next pops the answer to a prompt
+func (p *scriptedPrompter) next(label string) (string, error) {
+	if len(p.answers) == 0 {
+		return "", fmt.Errorf("no scripted answer left for %q", label)
+	}
+	answer := strings.TrimSpace(p.answers[0])
+	p.answers = p.answers[1:]
+	return answer, nil
+}
+
+func TestMatchItem(t *testing.T) {
+	items := []string{"Deploy to Staging", "Deploy to Production", "Run tests"}
+
+	tests := []struct {
+		answer  string
+		want    int
+		wantErr bool
+	}{
+		{answer: "2", want: 1},
+		{answer: "run tests", want: 2},
+		{answer: "staging", want: 0},
+		{answer: "deploy", wantErr: true},
+		{answer: "4", wantErr: true},
+		{answer: "lint", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := matchItem(items, test.answer)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("matchItem(%q) = %d, want an error", test.answer, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("matchItem(%q) = %d, %v, want %d", test.answer, got, err, test.want)
+		}
+	}
+}
+
+func TestLinePrompterAsksAgain(t *testing.T) {
+	var out bytes.Buffer
+	humanOut = &out
+	t.Cleanup(func() { humanOut = os.Stdout })
+
+	p := newLinePrompter(strings.NewReader("nothing\nprod\nmaybe\nyes\n"))
+
+	i, err := p.Select(SelectPrompt{
+		Label:   "Select Pipeline",
+		Items:   []string{"Deploy to Staging", "Deploy to Production"},
+		Details: []string{"Refs: main", "Refs: release"},
+	})
+	if err != nil || i != 1 {
+		t.Fatalf("Select() = %d, %v, want 1", i, err)
+	}
+
+	confirmed, err := p.Confirm("Are you sure you want to deploy")
+	if err != nil || !confirmed {
+		t.Fatalf("Confirm() = %v, %v, want true", confirmed, err)
+	}
+
+	if !strings.Contains(out.String(), "  2) Deploy to Production\n     Refs: release\n") {
+		t.Errorf("the details were not printed under their item:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), `nothing matches "nothing"`) {
+		t.Errorf("the invalid item was not reported:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "please type 'yes' or 'no'") {
+		t.Errorf("the invalid confirmation was not reported:\n%s", out.String())
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	buddy "github.com/JacobAndrewSmith92/gobuddy/internal"
+	"github.com/spf13/cobra"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [project]",
+	Short: "Re-run a pipeline on the revision it deployed before the current one",
+	Long: `This command finds the last two successful executions of a pipeline that deployed different revisions
+and re-runs the pipeline pinned to the older one. The pipeline can be passed with --pipeline or selected interactively.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		project := args[0]
+		config, err := requireConfig()
+		if err != nil {
+			return err
+		}
+
+		apiClient := newAPIClient(config, false)
+
+		runHooksOrWarn(loadHooks(config).BeforeSelection, HookEvent{Hook: "before_selection", Command: "rollback", Workspace: config.Workspace, Project: project})
+
+		slog.Info("Looking up project", "project", project)
+		if _, err := apiClient.FetchProjectByName(project); err != nil {
+			return err
+		}
+
+		var pipeline buddy.Pipeline
+		if pipelineFlag != "" {
+			pipelineFound, err := findPipeline(apiClient, project, pipelineFlag)
+			if err != nil {
+				return err
+			}
+			pipeline = *pipelineFound
+		} else {
+			pipelines, err := apiClient.FetchPipelines(project)
+			if err != nil {
+				return err
+			}
+			pipeline, err = searchPipeline(pipelines, "")
+			if err != nil {
+				return err
+			}
+		}
+
+		executions, err := apiClient.FetchExecutions(project, pipeline.ID)
+		if err != nil {
+			return err
+		}
+
+		current, previous := rollbackExecutions(executions)
+		if current == nil {
+			return classify(errNotFound, fmt.Errorf("pipeline %s has no successful executions", pipeline.Name))
+		}
+		if previous == nil {
+			return classify(errNotFound, fmt.Errorf("pipeline %s has no earlier successful execution of another revision to roll back to", pipeline.Name))
+		}
+
+		branch := previous.Branch.Name
+		revision := previous.ToRevision.Revision
+
+		if !pipelineMatchesBranch(pipeline, branch) {
+			slog.Warn("branch does not match the refs of the pipeline", "branch", branch, "pipeline", pipeline.Name, "refs", formatRefs(pipeline.Refs))
+		}
+
+		entry := newHistoryEntry("rollback", config, project, branch, pipeline, revision)
+		if err := checkProtection(config, pipeline, branch); err != nil {
+			return refuseDeployment(entry, err)
+		}
+
+		slog.Info("Rolling back project", "project", project, "branch", branch, "pipeline", pipeline.Name, "pipeline_id", pipeline.ID)
+		slog.Info("From revision", "revision", shortRevision(current.ToRevision.Revision), "subject", firstLine(current.ToRevision.Message), "execution_id", current.ID)
+		slog.Info("To revision", "revision", shortRevision(revision), "subject", firstLine(previous.ToRevision.Message), "execution_id", previous.ID)
+
+		return runDeployment(apiClient, config, entry)
+	},
+}
+
+func init() {
+	rollbackCmd.Flags().StringVarP(&pipelineFlag, "pipeline", "p", "", "Pipeline (name or ID) to roll back")
+	rollbackCmd.Flags().BoolVar(&cancelOnInterruptFlag, "cancel-on-interrupt", false, "Cancel the execution instead of detaching when interrupted while waiting")
+	rollbackCmd.ValidArgsFunction = completeFirstProject
+	rollbackCmd.RegisterFlagCompletionFunc("pipeline", completePipelines)
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+// rollbackExecutions returns the newest successful execution and the successful execution before it
+// that deployed a different revision
+func rollbackExecutions(executions []buddy.PipelineExecutionResponse) (*buddy.PipelineExecutionResponse, *buddy.PipelineExecutionResponse) {
+	current := lastSuccessfulExecution(executions)
+	if current == nil {
+		return nil, nil
+	}
+
+	for i := range executions {
+		execution := &executions[i]
+		if execution.Status == "SUCCESSFUL" && execution.ToRevision.Revision != current.ToRevision.Revision {
+			return current, execution
+		}
+	}
+	return current, nil
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+	"log/slog"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -8,21 +10,44 @@ import (
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "deploy",
+	Use:   "gobuddy",
 	Short: "a cli written in go for running buddy pipelines from the command line",
 	Long: `Go Buddy is a command-line tool designed to interact with the Buddy CI/CD Platform.
 	It allows users to manage and run pipelines for continuous integration (CI) and continuous deployment (CD).
 	With this tool, you can easily deploy to staging or production environments, ensuring a smooth and automated
 	workflow for your development and deployment processes.`,
 	Version: "1.1.0",
+	// Errors are rendered by Execute, usage is only printed for --help
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		setupAccessibility()
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		if err := setupTransport(); err != nil {
+			return err
+		}
+		setupPrompter()
+		return setupOutput(cmd)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Errors are logged and gobuddy exits with the code of their class, see exitCode.
+// Plugins report their own errors, gobuddy only exits with their code.
 func Execute() {
+	registerPlugins()
+	registerPresets()
+
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		var pluginErr *pluginExitError
+		if !errors.As(err, &pluginErr) {
+			slog.Error(err.Error())
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -32,6 +57,15 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.devops.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&refreshFlag, "refresh", false, "Ignore cached project, branch and pipeline lists")
+	rootCmd.PersistentFlags().StringVar(&recordFlag, "record", "", "Record every Buddy API request and response, scrubbed of secrets, as fixtures in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayFlag, "replay", "", "Serve Buddy API responses from fixtures recorded with --record instead of the network")
+	rootCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "table", "Output format: table, json, yaml or template=<go-template>")
+	rootCmd.PersistentFlags().BoolVar(&verboseFlag, "verbose", false, "Log debug messages, including every request sent to Buddy")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Only log warnings and errors")
+	rootCmd.PersistentFlags().StringVar(&logFormatFlag, "log-format", "text", "Log format: text or json")
+	rootCmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "Disable colors, also disabled by NO_COLOR or TERM=dumb")
+	rootCmd.PersistentFlags().BoolVar(&accessibleFlag, "accessible", false, "Numbered lists instead of arrow-key menus, plain status lines and no colors, for screen readers")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
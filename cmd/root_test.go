@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// useHome points the configuration, history and cache at a temporary directory
+func useHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+
+	oldConfigFile, oldConfigDir, oldHistory, oldCache, oldTrusted := configFilePath, configDir, historyFilePath, cacheDir, trustedHooksFilePath
+	configFilePath = filepath.Join(home, ".gobuddy_config.json")
+	configDir = filepath.Join(home, ".gobuddy")
+	historyFilePath = filepath.Join(configDir, "history.jsonl")
+	cacheDir = filepath.Join(configDir, "cache")
+	trustedHooksFilePath = filepath.Join(configDir, "trusted_hooks.json")
+	t.Cleanup(func() {
+		configFilePath, configDir, historyFilePath, cacheDir, trustedHooksFilePath = oldConfigFile, oldConfigDir, oldHistory, oldCache, oldTrusted
+	})
+	return home
+}
+
+// writeConfig saves the configuration in the temporary home
+func writeConfig(t *testing.T, config Config) {
+	t.Helper()
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runGobuddy runs gobuddy with args, answering prompts with answers, and returns the output meant for humans.
+// Every answer has to be used.
+func runGobuddy(t *testing.T, answers []string, args ...string) (string, error) {
+	t.Helper()
+	resetFlags(rootCmd)
+
+	var out bytes.Buffer
+	scripted := newScriptedPrompter(answers)
+	prompter, humanOut, dataOut = scripted, &out, &out
+	t.Cleanup(func() {
+		prompter, humanOut, dataOut = nil, os.Stdout, os.Stdout
+		apiTransport = http.DefaultTransport
+		resetFlags(rootCmd)
+	})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if len(scripted.answers) > 0 {
+		t.Errorf("unused answers: %q", scripted.answers)
+	}
+	return out.String(), err
+}
+
+// resetFlags sets every flag back to its default, flags keep their values between runs of rootCmd
+func resetFlags(cmd *cobra.Command) {
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		})
+	}
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
+
+func TestShortVersionFlag(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	if _, err := runGobuddy(t, nil, "-v"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "gobuddy version " + rootCmd.Version; !bytes.Contains(out.Bytes(), []byte(want)) {
+		t.Errorf("-v printed %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,160 @@
+package buddy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CachedClient decorates a BuddyClient with an on-disk cache of the project, branch and pipeline lists.
+// Cached lists are used until they are older than TTL and revalidated with their ETag afterwards.
+// Every other call goes straight to the wrapped client.
+type CachedClient struct {
+	*BuddyClient
+
+	// Dir holds the cached responses of the client's workspace
+	Dir string
+	// TTL is how long a cached list is used without asking the API
+	TTL time.Duration
+	// Refresh ignores cached lists, the fresh responses are still stored
+	Refresh bool
+	// AllowStale serves expired lists when the API is unreachable. Only read-only commands should set it.
+	AllowStale bool
+	// OnStale is called with the cached list's key and age when AllowStale served an expired list
+	OnStale func(key string, age time.Duration)
+	// Disabled sends every request to the API without reading or writing the cache
+	Disabled bool
+}
+
+// cacheEntry is a cached API response
+type cacheEntry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	ETag      string          `json:"etag,omitempty"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// NewCachedClient caches the lists of client below dir, in a directory per workspace
+func NewCachedClient(client *BuddyClient, dir string, ttl time.Duration) *CachedClient {
+	return &CachedClient{
+		BuddyClient: client,
+		Dir:         filepath.Join(dir, client.Workspace),
+		TTL:         ttl,
+	}
+}
+
+// FetchProjects fetches projects from the cache or the Buddy API
+func (c *CachedClient) FetchProjects() ([]Project, error) {
+	var projectResponse ProjectResponse
+	err := c.fetchList("projects", ProjectsPath(), &projectResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching projects: %w", err)
+	}
+	return projectResponse.Projects, nil
+}
+
+// FetchBranches fetches the branches of a project from the cache or the Buddy API
+func (c *CachedClient) FetchBranches(project string) ([]Branch, error) {
+	var branchResponse BranchResponse
+	err := c.fetchList("branches-"+project, BranchesPath(project), &branchResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching branches: %w", err)
+	}
+	return branchResponse.Branches, nil
+}
+
+// FetchPipelines fetches the pipelines of a project from the cache or the Buddy API
+func (c *CachedClient) FetchPipelines(project string) ([]Pipeline, error) {
+	var pipelineResponse PipelineResponse
+	err := c.fetchList("pipelines-"+project, PipelinesPath(project), &pipelineResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pipelines: %w", err)
+	}
+	return pipelineResponse.Pipelines, nil
+}
+
+// fetchList decodes the list at path into target, using the cached response of key when possible
+func (c *CachedClient) fetchList(key, path string, target any) error {
+	if c.Disabled {
+		body, _, err := c.FetchRaw(path, "")
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(body, target)
+	}
+
+	entry, cached := c.load(key)
+	if cached && !c.Refresh && time.Since(entry.FetchedAt) < c.TTL {
+		return json.Unmarshal(entry.Body, target)
+	}
+
+	etag := ""
+	if cached && !c.Refresh {
+		etag = entry.ETag
+	}
+
+	body, newETag, err := c.FetchRaw(path, etag)
+	switch {
+	case errors.Is(err, ErrNotModified):
+		body = entry.Body
+	case err != nil:
+		if cached && c.AllowStale && isUnreachable(err) {
+			if c.OnStale != nil {
+				c.OnStale(key, time.Since(entry.FetchedAt))
+			}
+			return json.Unmarshal(entry.Body, target)
+		}
+		return err
+	}
+
+	err = json.Unmarshal(body, target)
+	if err != nil {
+		return err
+	}
+
+	c.store(key, cacheEntry{FetchedAt: time.Now(), ETag: newETag, Body: body})
+	return nil
+}
+
+// load reads the cached response of key
+func (c *CachedClient) load(key string) (cacheEntry, bool) {
+	var entry cacheEntry
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// store writes the response of key. The cache is an optimization, so failing to write it is ignored.
+func (c *CachedClient) store(key string, entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path(key), data, 0600)
+}
+
+// path returns the file of a cache key, project names can't contain path separators but better safe than sorry
+func (c *CachedClient) path(key string) string {
+	return filepath.Join(c.Dir, strings.ReplaceAll(key, string(filepath.Separator), "_")+".json")
+}
+
+// isUnreachable reports whether err means the API could not be reached at all
+func isUnreachable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Compile time check that CachedClient implements BuddyAPI
+var _ BuddyAPI = (*CachedClient)(nil)
@@ -0,0 +1,174 @@
+package buddy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// projectsServer serves the project list with an ETag and answers 304 when it didn't change
+type projectsServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	project     string
+	requests    int
+	notModified int
+	// ifNoneMatch is the If-None-Match header of the last request
+	ifNoneMatch string
+}
+
+func newProjectsServer(t *testing.T, project string) *projectsServer {
+	t.Helper()
+	s := &projectsServer{project: project}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		s.ifNoneMatch = r.Header.Get("If-None-Match")
+
+		etag := `"` + s.project + `"`
+		if s.ifNoneMatch == etag {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"projects":[{"name":%q}]}`, s.project)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setProject changes the served list, and so its ETag
+func (s *projectsServer) setProject(project string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.project = project
+}
+
+// stats returns the number of requests, of 304 answers and the last If-None-Match header
+func (s *projectsServer) stats() (int, int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests, s.notModified, s.ifNoneMatch
+}
+
+// newTestCachedClient caches the lists of server in a temporary directory
+func newTestCachedClient(t *testing.T, server *projectsServer, ttl time.Duration) *CachedClient {
+	t.Helper()
+	client := NewBuddyClient("token", "acme")
+	client.HTTPClient = &http.Client{Transport: redirectTransport{server.Server}}
+	return NewCachedClient(client, t.TempDir(), ttl)
+}
+
+// fetchProject returns the name of the single project listed by c
+func fetchProject(t *testing.T, c *CachedClient) string {
+	t.Helper()
+	projects, err := c.FetchProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("got %d projects, want 1", len(projects))
+	}
+	return projects[0].Name
+}
+
+func TestCacheServesFreshLists(t *testing.T) {
+	server := newProjectsServer(t, "api")
+	c := newTestCachedClient(t, server, time.Hour)
+
+	fetchProject(t, c)
+	server.setProject("web")
+	if got := fetchProject(t, c); got != "api" {
+		t.Errorf("project = %s, want the cached api", got)
+	}
+	if requests, _, _ := server.stats(); requests != 1 {
+		t.Errorf("%d requests, want 1", requests)
+	}
+}
+
+func TestCacheRevalidatesExpiredLists(t *testing.T) {
+	server := newProjectsServer(t, "api")
+	c := newTestCachedClient(t, server, 0)
+
+	fetchProject(t, c)
+	if got := fetchProject(t, c); got != "api" {
+		t.Errorf("project = %s, want api", got)
+	}
+	requests, notModified, ifNoneMatch := server.stats()
+	if requests != 2 || notModified != 1 || ifNoneMatch != `"api"` {
+		t.Errorf("%d requests, %d not modified, If-None-Match %s, want 2, 1 and \"api\"", requests, notModified, ifNoneMatch)
+	}
+
+	server.setProject("web")
+	if got := fetchProject(t, c); got != "web" {
+		t.Errorf("project = %s, want the changed list web", got)
+	}
+}
+
+func TestCacheRefresh(t *testing.T) {
+	server := newProjectsServer(t, "api")
+	c := newTestCachedClient(t, server, time.Hour)
+	fetchProject(t, c)
+
+	server.setProject("web")
+	c.Refresh = true
+	if got := fetchProject(t, c); got != "web" {
+		t.Errorf("project = %s, want web", got)
+	}
+	if _, _, ifNoneMatch := server.stats(); ifNoneMatch != "" {
+		t.Errorf("If-None-Match = %s, want none when refreshing", ifNoneMatch)
+	}
+
+	// The refreshed list is stored
+	c.Refresh = false
+	server.setProject("worker")
+	if got := fetchProject(t, c); got != "web" {
+		t.Errorf("project = %s, want the refreshed web", got)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	server := newProjectsServer(t, "api")
+	c := newTestCachedClient(t, server, time.Hour)
+	c.Disabled = true
+
+	fetchProject(t, c)
+	fetchProject(t, c)
+	if requests, _, _ := server.stats(); requests != 2 {
+		t.Errorf("%d requests, want 2", requests)
+	}
+	if _, err := os.Stat(c.Dir); !os.IsNotExist(err) {
+		t.Errorf("the disabled cache wrote %s (%v)", c.Dir, err)
+	}
+}
+
+func TestCacheAllowStale(t *testing.T) {
+	server := newProjectsServer(t, "api")
+	c := newTestCachedClient(t, server, time.Millisecond)
+	fetchProject(t, c)
+	time.Sleep(2 * time.Millisecond)
+	server.Close()
+
+	if _, err := c.FetchProjects(); err == nil {
+		t.Fatal("an expired list was served without AllowStale")
+	}
+
+	var staleKey string
+	c.AllowStale = true
+	c.OnStale = func(key string, age time.Duration) {
+		staleKey = key
+	}
+	if got := fetchProject(t, c); got != "api" {
+		t.Errorf("project = %s, want the stale api", got)
+	}
+	if staleKey != "projects" {
+		t.Errorf("OnStale got %q, want projects", staleKey)
+	}
+}
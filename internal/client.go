@@ -7,50 +7,119 @@ package buddy
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	neturl "net/url"
 )
 
+// APIBaseURL is the root of the Buddy API
+const APIBaseURL = "https://api.buddy.works"
+
 // BuddyClient represents the actual Buddy API client
 type BuddyClient struct {
 	Token     string
 	Workspace string
+	// HTTPClient sends the requests, http.DefaultClient is used when it is nil
+	HTTPClient *http.Client
 }
 
 // NewBuddyClient initializes a new BuddyClient with token and workspace from the config
 func NewBuddyClient(token, workspace string) *BuddyClient {
 	return &BuddyClient{
-		Token:     token,
-		Workspace: workspace,
+		Token:      token,
+		Workspace:  workspace,
+		HTTPClient: &http.Client{},
 	}
 }
 
-// FetchProjects fetches projects from the Buddy API
-func (c *BuddyClient) FetchProjects() ([]Project, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects?per_page=100", c.Workspace)
+// httpClient returns the client sending the requests
+func (c *BuddyClient) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// StatusError is returned when the Buddy API responds with an unexpected status
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return e.Status
+}
+
+// newStatusError describes an unexpected response, keeping its status available through errors.As
+func newStatusError(message string, resp *http.Response) error {
+	return fmt.Errorf("%s: %w", message, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+}
+
+// ErrNotModified is returned by FetchRaw when the response still matches the given ETag
+var ErrNotModified = errors.New("not modified")
+
+// ProjectsPath is the workspace relative path listing the projects
+func ProjectsPath() string {
+	return "/projects?per_page=100"
+}
+
+// BranchesPath is the workspace relative path listing the branches of a project
+func BranchesPath(project string) string {
+	return fmt.Sprintf("/projects/%s/repository/branches", project)
+}
+
+// PipelinesPath is the workspace relative path listing the pipelines of a project
+func PipelinesPath(project string) string {
+	return fmt.Sprintf("/projects/%s/pipelines", project)
+}
+
+// FetchRaw fetches the body of a workspace relative path. When etag is set the request is conditional
+// and ErrNotModified is returned if the response did not change. The ETag of the response is returned
+// so callers can revalidate cached responses.
+func (c *BuddyClient) FetchRaw(path, etag string) ([]byte, string, error) {
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s%s", c.Workspace, path)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
+	if etag != "" && resp.StatusCode == http.StatusNotModified {
+		return nil, etag, ErrNotModified
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching projects: %s", resp.Status)
+		return nil, "", &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	return body, resp.Header.Get("ETag"), nil
+}
+
+// FetchProjects fetches projects from the Buddy API
+func (c *BuddyClient) FetchProjects() ([]Project, error) {
+	body, _, err := c.FetchRaw(ProjectsPath(), "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching projects: %w", err)
 	}
 
 	var projectResponse ProjectResponse
@@ -64,8 +133,8 @@ func (c *BuddyClient) FetchProjects() ([]Project, error) {
 
 // FetchProjectByName fetches a project by name from the Buddy API
 func (c *BuddyClient) FetchProjectByName(name string) (*Project, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects/%s", c.Workspace, name)
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s", c.Workspace, name)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -81,11 +150,11 @@ func (c *BuddyClient) FetchProjectByName(name string) (*Project, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("project %s not found - status: %s", name, resp.Status)
+		return nil, newStatusError(fmt.Sprintf("project %s not found", name), resp)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching project: %s", resp.Status)
+		return nil, newStatusError("error fetching project", resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -104,8 +173,24 @@ func (c *BuddyClient) FetchProjectByName(name string) (*Project, error) {
 
 // FetchBranches fetches branches for a specific project
 func (c *BuddyClient) FetchBranches(project string) ([]Branch, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects/%s/repository/branches", c.Workspace, project)
+	body, _, err := c.FetchRaw(BranchesPath(project), "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching branches: %w", err)
+	}
+
+	var branchResponse BranchResponse
+	err = json.Unmarshal(body, &branchResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return branchResponse.Branches, nil
+}
+
+// FetchBranchByName fetches a branch by name for a given project
+func (c *BuddyClient) FetchBranchByName(project, branch string) (*Branch, error) {
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s/repository/branches/%s", c.Workspace, project, branch)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -120,8 +205,12 @@ func (c *BuddyClient) FetchBranches(project string) ([]Branch, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, newStatusError(fmt.Sprintf("branch %s not found in project %s", branch, project), resp)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching branches: %s", resp.Status)
+		return nil, newStatusError("error fetching branch", resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -129,87 +218,59 @@ func (c *BuddyClient) FetchBranches(project string) ([]Branch, error) {
 		return nil, err
 	}
 
-	var branchResponse BranchResponse
+	var branchResponse Branch
 	err = json.Unmarshal(body, &branchResponse)
 	if err != nil {
 		return nil, err
 	}
 
-	return branchResponse.Branches, nil
+	return &branchResponse, nil
 }
 
-// FetchBranchByName fetches a branch by name for a given project
-func (c *BuddyClient) FetchBranchByName(project, branch string) (*Branch, *ErrorResponse) {
-	client := &http.Client{}
-
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects/%s/repository/branches/%s", c.Workspace, project, branch)
+// FetchLatestCommit fetches the commit at the tip of a branch
+func (c *BuddyClient) FetchLatestCommit(project, branch string) (*Revision, error) {
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s/repository/commits?branch=%s&per_page=1", c.Workspace, project, neturl.QueryEscape(branch))
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, &ErrorResponse{
-			Errors: []ErrorDetail{
-				{
-					Message: fmt.Sprintf("Failed to build request to get branch: %v", err),
-				},
-			},
-		}
+		return nil, err
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, &ErrorResponse{
-			Errors: []ErrorDetail{
-				{
-					Message: fmt.Sprintf("Failed to fetch branch: %v", err),
-				},
-			},
-		}
+		return nil, err
 	}
-
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, &ErrorResponse{
-			Errors: []ErrorDetail{
-				{
-					Message: fmt.Sprintf("Branch %s not found in project %s", branch, project),
-				},
-			},
-		}
+		return nil, newStatusError("error fetching commits", resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &ErrorResponse{
-			Errors: []ErrorDetail{
-				{
-					Message: fmt.Sprintf("Failed to read response body: %v", err),
-				},
-			},
-		}
+		return nil, err
 	}
 
-	var branchResponse Branch
-	err = json.Unmarshal(body, &branchResponse)
+	var commitResponse CommitResponse
+	err = json.Unmarshal(body, &commitResponse)
 	if err != nil {
-		return nil, &ErrorResponse{
-			Errors: []ErrorDetail{
-				{
-					Message: fmt.Sprintf("Failed to unmarshal branch response: %v", err),
-				},
-			},
-		}
+		return nil, err
 	}
 
-	return &branchResponse, nil
+	if len(commitResponse.Commits) == 0 {
+		return nil, fmt.Errorf("no commits found on branch %s in project %s", branch, project)
+	}
+
+	return &commitResponse.Commits[0], nil
 }
 
-// FetchPipelines fetches pipelines for a specific project
-func (c *BuddyClient) FetchPipelines(project string) ([]Pipeline, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects/%s/pipelines", c.Workspace, project)
+// CompareRevisions fetches the commits and file changes between two revisions of a project's repository
+func (c *BuddyClient) CompareRevisions(project, base, head string) (*Comparison, error) {
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s/repository/comparison/%s...%s", c.Workspace, project, base, head)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -225,7 +286,7 @@ func (c *BuddyClient) FetchPipelines(project string) ([]Pipeline, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching pipelines: %s", resp.Status)
+		return nil, newStatusError("error comparing revisions", resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -233,6 +294,22 @@ func (c *BuddyClient) FetchPipelines(project string) ([]Pipeline, error) {
 		return nil, err
 	}
 
+	var comparison Comparison
+	err = json.Unmarshal(body, &comparison)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comparison, nil
+}
+
+// FetchPipelines fetches pipelines for a specific project
+func (c *BuddyClient) FetchPipelines(project string) ([]Pipeline, error) {
+	body, _, err := c.FetchRaw(PipelinesPath(project), "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pipelines: %w", err)
+	}
+
 	var pipelineResponse PipelineResponse
 	err = json.Unmarshal(body, &pipelineResponse)
 	if err != nil {
@@ -245,8 +322,8 @@ func (c *BuddyClient) FetchPipelines(project string) ([]Pipeline, error) {
 // FetchPipelineByID fetches a pipeline for a specific project by ID
 // - can be used if dev knows the pipeline ID or I need to do some more logic to map name to ID
 func (c *BuddyClient) FetchPipelineByID(project, id string) (*Pipeline, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects/%s/pipelines/%s", c.Workspace, project, id)
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s/pipelines/%s", c.Workspace, project, id)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -262,7 +339,7 @@ func (c *BuddyClient) FetchPipelineByID(project, id string) (*Pipeline, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching pipelines: %s", resp.Status)
+		return nil, newStatusError("error fetching pipelines", resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -279,19 +356,66 @@ func (c *BuddyClient) FetchPipelineByID(project, id string) (*Pipeline, error) {
 	return &pipelineResponse, nil
 }
 
-// RunPipeline triggers the execution of a pipeline
-func (c *BuddyClient) RunPipeline(project string, pipelineID int, branch string) (*PipelineExecutionResponse, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects/%s/pipelines/%d/executions", c.Workspace, project, pipelineID)
+// ExecutionsURL returns the endpoint used to list and trigger executions of a pipeline
+func (c *BuddyClient) ExecutionsURL(project string, pipelineID int) string {
+	return fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s/pipelines/%d/executions", c.Workspace, project, pipelineID)
+}
 
-	requestBody := PipelineExecutionRequest{
+// NewPipelineExecutionRequest builds the payload RunPipeline sends to trigger a pipeline on a branch
+// at the given revision, "HEAD" runs the latest commit of the branch
+func NewPipelineExecutionRequest(branch, revision string) PipelineExecutionRequest {
+	return PipelineExecutionRequest{
 		ToRevision: Revision{
-			Revision: "HEAD",
+			Revision: revision,
 		},
 		Branch: Branch{
 			Name: branch,
 		},
 	}
+}
+
+// FetchExecutions fetches the most recent executions of a pipeline, newest first
+func (c *BuddyClient) FetchExecutions(project string, pipelineID int) ([]PipelineExecutionResponse, error) {
+	client := c.httpClient()
+	url := c.ExecutionsURL(project, pipelineID) + "?per_page=50"
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("error fetching executions", resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var executionsResponse PipelineExecutionsResponse
+	err = json.Unmarshal(body, &executionsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return executionsResponse.Executions, nil
+}
+
+// RunPipeline triggers the execution of a pipeline on a branch at the given revision
+func (c *BuddyClient) RunPipeline(project string, pipelineID int, branch, revision string) (*PipelineExecutionResponse, error) {
+	client := c.httpClient()
+	url := c.ExecutionsURL(project, pipelineID)
+
+	requestBody := NewPipelineExecutionRequest(branch, revision)
 
 	jsonBody, err := json.Marshal(requestBody)
 	if err != nil {
@@ -313,8 +437,9 @@ func (c *BuddyClient) RunPipeline(project string, pipelineID int, branch string)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		fmt.Printf("Response Body: %s\n", resp.Body)
-		return nil, fmt.Errorf("error executing pipeline: %s", resp.Status)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		slog.Debug("pipeline execution refused", "status", resp.StatusCode, "body", string(body))
+		return nil, newStatusError("error executing pipeline", resp)
 	}
 
 	var executionResponse PipelineExecutionResponse
@@ -328,8 +453,18 @@ func (c *BuddyClient) RunPipeline(project string, pipelineID int, branch string)
 
 // CheckPipelineStatus fetches the status of a pipeline execution
 func (c *BuddyClient) CheckPipelineStatus(project string, pipeline int, executionID int) (*string, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("https://api.buddy.works/workspaces/%s/projects/%s/pipelines/%d/executions/%d", c.Workspace, project, pipeline, executionID)
+	execution, err := c.FetchExecution(project, pipeline, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &execution.Status, nil
+}
+
+// FetchExecution fetches the details of a pipeline execution
+func (c *BuddyClient) FetchExecution(project string, pipeline int, executionID int) (*PipelineExecutionResponse, error) {
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s/pipelines/%d/executions/%d", c.Workspace, project, pipeline, executionID)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -344,7 +479,44 @@ func (c *BuddyClient) CheckPipelineStatus(project string, pipeline int, executio
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching pipeline status: %s", resp.Status)
+		return nil, newStatusError("error fetching pipeline status", resp)
+	}
+
+	var executionResponse PipelineExecutionResponse
+	err = json.NewDecoder(resp.Body).Decode(&executionResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	return &executionResponse, nil
+}
+
+// CancelExecution cancels a running pipeline execution
+func (c *BuddyClient) CancelExecution(project string, pipeline int, executionID int) (*PipelineExecutionResponse, error) {
+	client := c.httpClient()
+	url := fmt.Sprintf(APIBaseURL+"/workspaces/%s/projects/%s/pipelines/%d/executions/%d", c.Workspace, project, pipeline, executionID)
+
+	jsonBody, err := json.Marshal(map[string]string{"operation": "CANCEL"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %v", err)
+	}
+
+	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("error canceling execution", resp)
 	}
 
 	var executionResponse PipelineExecutionResponse
@@ -353,5 +525,5 @@ func (c *BuddyClient) CheckPipelineStatus(project string, pipeline int, executio
 		return nil, fmt.Errorf("failed to decode response: %v", err)
 	}
 
-	return &executionResponse.Status, nil
+	return &executionResponse, nil
 }
@@ -2,6 +2,8 @@
 
 package buddy
 
+import "strings"
+
 // BuddyAPI defines the interface for interacting with Buddy
 type BuddyAPI interface {
 	FetchProjects() ([]Project, error)
@@ -9,11 +11,20 @@ type BuddyAPI interface {
 	FetchPipelines(project string) ([]Pipeline, error)
 	FetchProjectByName(name string) (*Project, error)
 	FetchBranchByName(project, name string) (*Branch, error)
-	FetchPipelineByID(project string, id int) (*Pipeline, error)
-	RunPipeline(project string, pipelineID int, branch string) (*PipelineExecutionResponse, error)
+	FetchPipelineByID(project, id string) (*Pipeline, error)
+	FetchLatestCommit(project, branch string) (*Revision, error)
+	CompareRevisions(project, base, head string) (*Comparison, error)
+	FetchExecutions(project string, pipelineID int) ([]PipelineExecutionResponse, error)
+	RunPipeline(project string, pipelineID int, branch, revision string) (*PipelineExecutionResponse, error)
 	CheckPipelineStatus(project string, pipeline int, executionID int) (*string, error)
+	FetchExecution(project string, pipeline int, executionID int) (*PipelineExecutionResponse, error)
+	CancelExecution(project string, pipeline int, executionID int) (*PipelineExecutionResponse, error)
+	ExecutionsURL(project string, pipelineID int) string
 }
 
+// Compile time check that BuddyClient implements BuddyAPI
+var _ BuddyAPI = (*BuddyClient)(nil)
+
 type ProjectResponse struct {
 	URL      string    `json:"url"`
 	HTMLURL  string    `json:"html_url"`
@@ -81,6 +92,29 @@ type Revision struct {
 	Author     Author    `json:"author,omitempty"`
 }
 
+// CommitResponse is the list of commits of a repository
+type CommitResponse struct {
+	URL     string     `json:"url"`
+	HTMLURL string     `json:"html_url"`
+	Commits []Revision `json:"commits"`
+}
+
+// Comparison represents the commits and changed files between two revisions
+type Comparison struct {
+	URL     string     `json:"url"`
+	HTMLURL string     `json:"html_url"`
+	Commits []Revision `json:"commits"`
+	Changes []Change   `json:"changes"`
+}
+
+// Change represents a file changed between two revisions
+type Change struct {
+	FileName  string `json:"file_name"`
+	Status    string `json:"status,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
 // PipelineExecutionRequest represents the payload to trigger the pipeline execution
 type PipelineExecutionRequest struct {
 	ToRevision Revision `json:"to_revision"`
@@ -119,6 +153,13 @@ type PipelineExecutionResponse struct {
 	// ActionExecutions []ActionExecution `json:"action_executions"`
 }
 
+// PipelineExecutionsResponse is the list of executions of a pipeline
+type PipelineExecutionsResponse struct {
+	URL        string                      `json:"url"`
+	HTMLURL    string                      `json:"html_url"`
+	Executions []PipelineExecutionResponse `json:"executions"`
+}
+
 type ErrorDetail struct {
 	Message string `json:"message,omitempty"`
 }
@@ -127,3 +168,12 @@ type ErrorDetail struct {
 type ErrorResponse struct {
 	Errors []ErrorDetail `json:"errors"`
 }
+
+// Error joins the messages of the error details
+func (e *ErrorResponse) Error() string {
+	var messages []string
+	for _, detail := range e.Errors {
+		messages = append(messages, detail.Message)
+	}
+	return strings.Join(messages, ", ")
+}
@@ -0,0 +1,73 @@
+package buddy
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// redactedHeaders are never logged, their values are replaced
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+}
+
+// LoggingTransport logs every request sent through it at debug level,
+// with its method, URL, status and latency
+type LoggingTransport struct {
+	// Base sends the requests, http.DefaultTransport is used when it is nil
+	Base http.RoundTripper
+	// Logger receives the request logs, slog.Default() is used when it is nil
+	Logger *slog.Logger
+}
+
+// RoundTrip sends the request with the base transport and logs it
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	logger := t.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ctx := req.Context()
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.Duration("latency", time.Since(start)),
+		headerAttr(req.Header),
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelDebug, "HTTP request failed", append(attrs, slog.String("error", err.Error()))...)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "HTTP request", append(attrs, slog.Int("status", resp.StatusCode))...)
+	return resp, nil
+}
+
+// headerAttr groups the request headers sorted by name, redacting credentials
+func headerAttr(header http.Header) slog.Attr {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var attrs []any
+	for _, name := range names {
+		value := header.Get(name)
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.Group("headers", attrs...)
+}
@@ -0,0 +1,315 @@
+package buddy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces scrubbed secrets in fixtures
+const redacted = "REDACTED"
+
+// sensitiveKeys are JSON keys whose values are scrubbed from recorded bodies
+var sensitiveKeys = []string{"token", "password", "secret", "private_key", "passphrase", "authorization"}
+
+// workspacePath matches the workspace segment of Buddy API paths, fixtures are replayed in any workspace
+var workspacePath = regexp.MustCompile(`^/workspaces/[^/]+`)
+
+// unsafeFileName matches the characters of a request path that don't belong in a fixture file name
+var unsafeFileName = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Fixture is a recorded Buddy API request and its response
+type Fixture struct {
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	RequestHeaders map[string]string `json:"request_headers,omitempty"`
+	RequestBody    json.RawMessage   `json:"request_body,omitempty"`
+	Status         int               `json:"status"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	// Body holds JSON responses, Text any other response
+	Body json.RawMessage `json:"body,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// RecordingTransport writes every request sent through it and its response to a fixture file in Dir.
+// Credentials and secrets are scrubbed before anything is written.
+type RecordingTransport struct {
+	// Base sends the requests, http.DefaultTransport is used when it is nil
+	Base http.RoundTripper
+	Dir  string
+
+	mu    sync.Mutex
+	count int
+}
+
+// NewRecordingTransport records the requests sent by base into dir, creating it if needed
+func NewRecordingTransport(base http.RoundTripper, dir string) (*RecordingTransport, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &RecordingTransport{Base: base, Dir: dir}, nil
+}
+
+// RoundTrip sends the request with the base transport and records the exchange
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	fixture := Fixture{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: scrubHeaders(req.Header),
+		Status:         resp.StatusCode,
+		Headers:        scrubHeaders(resp.Header),
+	}
+	if json.Valid(requestBody) {
+		fixture.RequestBody = scrubJSON(requestBody, token)
+	}
+	if json.Valid(body) {
+		fixture.Body = scrubJSON(body, token)
+	} else if len(body) > 0 {
+		fixture.Text = scrubString(string(body), token)
+	}
+
+	if err := t.write(req, fixture); err != nil {
+		return nil, fmt.Errorf("unable to record %s %s: %w", req.Method, req.URL, err)
+	}
+	return resp, nil
+}
+
+// write stores a fixture in a file named after its position and request
+func (t *RecordingTransport) write(req *http.Request, fixture Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.count++
+	name := fmt.Sprintf("%04d-%s%s.json", t.count, strings.ToLower(req.Method), fixtureName(req.URL.Path))
+	t.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(t.Dir, name), data, 0600)
+}
+
+// ReplayTransport serves recorded fixtures instead of sending requests. Requests are matched by method,
+// path and query, ignoring the workspace. Fixtures of the same request are served in the order they
+// were recorded and the last one is repeated, so polling replays the recorded statuses.
+type ReplayTransport struct {
+	mu       sync.Mutex
+	fixtures map[string][]Fixture
+}
+
+// NewReplayTransport loads the fixtures recorded in dir
+func NewReplayTransport(dir string) (*ReplayTransport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+	sort.Strings(files)
+
+	t := &ReplayTransport{fixtures: make(map[string][]Fixture)}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("unable to parse fixture %s: %w", file, err)
+		}
+		key, err := fixtureKey(fixture.Method, fixture.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in fixture %s: %w", file, err)
+		}
+		t.fixtures[key] = append(t.fixtures[key], fixture)
+	}
+	return t, nil
+}
+
+// RoundTrip answers the request with its next recorded response
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key, err := fixtureKey(req.Method, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	fixtures := t.fixtures[key]
+	if len(fixtures) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	fixture := fixtures[0]
+	if len(fixtures) > 1 {
+		t.fixtures[key] = fixtures[1:]
+	}
+	t.mu.Unlock()
+
+	body := []byte(fixture.Text)
+	if len(fixture.Body) > 0 {
+		body = fixture.Body
+	}
+
+	header := make(http.Header)
+	for name, value := range fixture.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		StatusCode:    fixture.Status,
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// fixtureKey identifies a request by method, path and query, without the workspace
+func fixtureKey(method, rawURL string) (string, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	path := workspacePath.ReplaceAllString(req.URL.Path, "/workspaces/*")
+	if req.URL.RawQuery != "" {
+		path += "?" + req.URL.RawQuery
+	}
+	return method + " " + path, nil
+}
+
+// fixtureName turns a request path into a readable file name suffix
+func fixtureName(path string) string {
+	path = workspacePath.ReplaceAllString(path, "")
+	name := strings.Trim(unsafeFileName.ReplaceAllString(path, "-"), "-")
+	if len(name) > 60 {
+		name = name[:60]
+	}
+	if name == "" {
+		return ""
+	}
+	return "-" + name
+}
+
+// scrubHeaders flattens headers, redacting credentials and dropping cookies. Content-Length is dropped
+// as well, scrubbing changes the length of the body.
+func scrubHeaders(header http.Header) map[string]string {
+	scrubbed := make(map[string]string)
+	for name := range header {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization":
+			scrubbed[name] = redacted
+		case "Set-Cookie", "Cookie", "Content-Length":
+		default:
+			scrubbed[name] = header.Get(name)
+		}
+	}
+	return scrubbed
+}
+
+// scrubJSON redacts the values of sensitive keys and every occurrence of the token in a JSON document.
+// A document that can't be scrubbed is redacted as a whole.
+func scrubJSON(data []byte, token string) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return json.RawMessage(`"` + redacted + `"`)
+	}
+
+	scrubbed, err := json.Marshal(scrubValue(value, token))
+	if err != nil {
+		return json.RawMessage(`"` + redacted + `"`)
+	}
+	return scrubbed
+}
+
+// scrubValue walks a decoded JSON value and redacts secrets
+func scrubValue(value any, token string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitiveKey(key) {
+				if _, isString := field.(string); isString {
+					v[key] = redacted
+					continue
+				}
+			}
+			v[key] = scrubValue(field, token)
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = scrubValue(v[i], token)
+		}
+		return v
+	case string:
+		return scrubString(v, token)
+	default:
+		return v
+	}
+}
+
+// isSensitiveKey reports whether a JSON key holds a secret
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubString redacts the token in a string
+func scrubString(value, token string) string {
+	if token == "" {
+		return value
+	}
+	return strings.ReplaceAll(value, token, redacted)
+}
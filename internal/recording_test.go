@@ -0,0 +1,166 @@
+package buddy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScrubJSON(t *testing.T) {
+	body := []byte(`{"name":"api","token":"abc","nested":{"private_key":"key","note":"Bearer secret-token in a message","count":3},"list":["secret-token"]}`)
+
+	scrubbed := string(scrubJSON(body, "secret-token"))
+
+	if strings.Contains(scrubbed, "secret-token") {
+		t.Errorf("the token was not scrubbed: %s", scrubbed)
+	}
+	for _, kept := range []string{`"token":"REDACTED"`, `"private_key":"REDACTED"`, `"name":"api"`, `"count":3`, `"note":"Bearer REDACTED in a message"`, `"list":["REDACTED"]`} {
+		if !strings.Contains(scrubbed, kept) {
+			t.Errorf("scrubbed body lost %s: %s", kept, scrubbed)
+		}
+	}
+}
+
+func TestScrubJSONInvalid(t *testing.T) {
+	if got := string(scrubJSON([]byte(`{"token":`), "secret-token")); got != `"REDACTED"` {
+		t.Errorf("scrubJSON of invalid JSON = %s, want the whole document redacted", got)
+	}
+}
+
+func TestScrubHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret-token")
+	header.Set("Cookie", "session=1")
+	header.Set("Set-Cookie", "session=2")
+	header.Set("Content-Length", "42")
+	header.Set("Content-Type", "application/json")
+
+	scrubbed := scrubHeaders(header)
+
+	if scrubbed["Authorization"] != redacted {
+		t.Errorf("Authorization = %q, want %q", scrubbed["Authorization"], redacted)
+	}
+	for _, dropped := range []string{"Cookie", "Set-Cookie", "Content-Length"} {
+		if _, ok := scrubbed[dropped]; ok {
+			t.Errorf("%s was recorded", dropped)
+		}
+	}
+	if scrubbed["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", scrubbed["Content-Type"])
+	}
+}
+
+// writeFixture saves a fixture the way RecordingTransport does
+func writeFixture(t *testing.T, dir, name string, fixture Fixture) {
+	t.Helper()
+	data, err := json.Marshal(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayTransportOrder(t *testing.T) {
+	dir := t.TempDir()
+	url := APIBaseURL + "/workspaces/acme/projects/api/pipelines/1/executions/7"
+	for i, status := range []string{"ENQUEUED", "INPROGRESS", "SUCCESSFUL"} {
+		writeFixture(t, dir, fmt.Sprintf("%04d-get-execution.json", i+1), Fixture{
+			Method: "GET",
+			URL:    url,
+			Status: http.StatusOK,
+			Body:   json.RawMessage(fmt.Sprintf(`{"id":7,"status":%q}`, status)),
+		})
+	}
+
+	transport, err := NewReplayTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewBuddyClient("token", "other-workspace")
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	// The last fixture repeats once the others were served, and the workspace doesn't matter
+	for _, want := range []string{"ENQUEUED", "INPROGRESS", "SUCCESSFUL", "SUCCESSFUL"} {
+		status, err := client.CheckPipelineStatus("api", 1, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *status != want {
+			t.Errorf("status = %s, want %s", *status, want)
+		}
+	}
+
+	if _, err := client.CheckPipelineStatus("api", 1, 8); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("unrecorded request: err = %v, want no recorded response", err)
+	}
+}
+
+// redirectTransport sends every request to a test server
+type redirectTransport struct {
+	server *httptest.Server
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	redirected := req.Clone(req.Context())
+	redirected.URL.Scheme = "http"
+	redirected.URL.Host = strings.TrimPrefix(t.server.URL, "http://")
+	return http.DefaultTransport.RoundTrip(redirected)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"name":"api","display_name":"API","token":"project-token"}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder, err := NewRecordingTransport(redirectTransport{server}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewBuddyClient("secret-token", "acme")
+	client.HTTPClient = &http.Client{Transport: recorder}
+	if _, err := client.FetchProjectByName("api"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 || filepath.Base(files[0]) != "0001-get-projects-api.json" {
+		t.Fatalf("recorded %v, want 0001-get-projects-api.json", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-token", "project-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("fixture contains %s:\n%s", secret, data)
+		}
+	}
+
+	replay, err := NewReplayTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: replay}
+	project, err := client.FetchProjectByName("api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.Name != "api" || project.DisplayName != "API" {
+		t.Errorf("replayed project = %+v, want api", project)
+	}
+}
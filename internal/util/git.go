@@ -70,3 +70,159 @@ func GetCurrentDirectoryName() (string, error) {
 	// Extract the base (last element) of the path
 	return filepath.Base(currentPath), nil
 }
+
+// GitStatus describes how the local branch differs from the branch it tracks
+type GitStatus struct {
+	// Uncommitted lists the changed files reported by git status
+	Uncommitted []string
+	// Upstream is the tracking branch, e.g. origin/master. It is empty when the branch does not track one.
+	Upstream string
+	// Ahead is the number of local commits that are not on the upstream
+	Ahead int
+	// Behind is the number of upstream commits that are not in the local branch
+	Behind int
+}
+
+// Pushed reports whether the local HEAD is on the upstream
+func (s GitStatus) Pushed() bool {
+	return s.Upstream != "" && s.Ahead == 0
+}
+
+// GetGitStatus fetches the upstream and compares it with the local branch.
+// A failed fetch is ignored and the last known state of the upstream is used.
+func GetGitStatus() (GitStatus, error) {
+	var status GitStatus
+	if !checkIfGitRepo() {
+		return status, fmt.Errorf("not a git repository")
+	}
+
+	output, err := exec.Command("git", "status", "--porcelain").Output()
+	if err != nil {
+		return status, fmt.Errorf("unable to read git status: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if line != "" {
+			status.Uncommitted = append(status.Uncommitted, line)
+		}
+	}
+
+	output, err = exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}").Output()
+	if err != nil {
+		// No upstream configured, nothing to compare against
+		return status, nil
+	}
+	status.Upstream = strings.TrimSpace(string(output))
+
+	fetch := exec.Command("git", "fetch", "--quiet")
+	fetch.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	_ = fetch.Run()
+
+	output, err = exec.Command("git", "rev-list", "--left-right", "--count", "HEAD...@{u}").Output()
+	if err != nil {
+		return status, fmt.Errorf("unable to compare with %s: %v", status.Upstream, err)
+	}
+	_, err = fmt.Sscanf(string(output), "%d %d", &status.Ahead, &status.Behind)
+	if err != nil {
+		return status, fmt.Errorf("unable to parse commit counts: %v", err)
+	}
+
+	return status, nil
+}
+
+// PushBranch pushes the local branch to the remote and sets it as the upstream
+func PushBranch(remote, branch string) error {
+	cmd := exec.Command("git", "push", "--set-upstream", remote, branch)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to push %s to %s: %v", branch, remote, err)
+	}
+	return nil
+}
+
+// GetHeadCommit returns the SHA and subject of the commit checked out in the repository
+func GetHeadCommit() (string, string, error) {
+	if !checkIfGitRepo() {
+		return "", "", fmt.Errorf("not a git repository")
+	}
+	output, err := exec.Command("git", "log", "-1", "--format=%H%n%s").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read the current commit: %v", err)
+	}
+	sha, subject, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return sha, subject, nil
+}
+
+// Commit is a commit read from the local repository
+type Commit struct {
+	SHA     string
+	Author  string
+	Subject string
+}
+
+// FileChange is the number of lines changed in a file between two commits
+type FileChange struct {
+	Path      string
+	Additions int
+	Deletions int
+}
+
+// HasCommit reports whether the commit exists in the local repository
+func HasCommit(sha string) bool {
+	if !checkIfGitRepo() {
+		return false
+	}
+	return exec.Command("git", "cat-file", "-e", sha+"^{commit}").Run() == nil
+}
+
+// GetCommitsBetween returns the commits reachable from head but not from base, newest first
+func GetCommitsBetween(base, head string) ([]Commit, error) {
+	output, err := exec.Command("git", "log", "--format=%H%x1f%an%x1f%s", base+".."+head).Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read commits between %s and %s: %v", base, head, err)
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{SHA: fields[0], Author: fields[1], Subject: fields[2]})
+	}
+	return commits, nil
+}
+
+// GetChangedFiles returns the files changed between base and head with their line counts.
+// Binary files are reported without line counts.
+func GetChangedFiles(base, head string) ([]FileChange, error) {
+	output, err := exec.Command("git", "diff", "--numstat", base, head).Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to diff %s and %s: %v", base, head, err)
+	}
+
+	var changes []FileChange
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		change := FileChange{Path: fields[2]}
+		fmt.Sscanf(fields[0], "%d", &change.Additions)
+		fmt.Sscanf(fields[1], "%d", &change.Deletions)
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// GetRepoRoot returns the top level directory of the repository
+func GetRepoRoot() (string, error) {
+	if !checkIfGitRepo() {
+		return "", fmt.Errorf("not a git repository")
+	}
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the repository root: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}